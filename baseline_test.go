@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/project-brian-api-test/perf"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	perfBaselines = flag.String("perf.baselines", "resources/baselines", "the directory of the performance baselines")
+	perfSamples   = flag.Int("perf.samples", 3, "compare the median latency of this many conversions of each dataset with its baseline")
+	perfWarn      = flag.Float64("perf.warn", 20, "warn when latency or response size grows by more than this percentage of its baseline")
+	perfFail      = flag.Float64("perf.fail", 0, "fail when latency or response size grows by more than this percentage of its baseline, never if 0")
+	perfRecord    = flag.Bool("perf.record", false, "record the latency and response size of this run as the new baselines")
+)
+
+// checkPerformanceBaseline compares the median latency of converting filename, the given conversion and
+// -perf.samples - 1 more, and its response size with the baseline of the dataset, e.g.
+// resources/baselines/ott.perf.json. Without a baseline, or -perf.record, filename is not converted again.
+func checkPerformanceBaseline(t *testing.T, filename string, latency time.Duration, responseBytes int64) {
+	path := perf.BaselinePath(*perfBaselines, filename)
+
+	var baseline *perf.Baseline
+	if !*perfRecord {
+		var err error
+		baseline, err = perf.ReadBaseline(path)
+		require.Nil(t, err, Err("error reading performance baseline"))
+		if baseline == nil {
+			info(t, fmt.Sprintf("no performance baseline %s, run with -perf.record to create one", path))
+			return
+		}
+	}
+
+	latency = medianLatency(t, filename, latency)
+
+	if *perfRecord {
+		err := perf.WriteBaseline(path, perf.NewBaseline(latency, responseBytes))
+		require.Nil(t, err, Err("error writing performance baseline"))
+		info(t, fmt.Sprintf("recorded baseline %s: latency %s, response size %d bytes", path, latency.Round(time.Millisecond), responseBytes))
+		return
+	}
+
+	for _, regression := range baseline.Compare(latency, responseBytes, *perfWarn, *perfFail) {
+		if regression.Fail {
+			t.Error(Err(fmt.Sprintf("performance regression over %.0f%%: %s", *perfFail, regression)))
+		} else {
+			warn(t, fmt.Sprintf("performance regression over %.0f%%: %s", *perfWarn, regression))
+		}
+	}
+}
+
+// medianLatency converts filename until there are -perf.samples latencies, including the first, and returns their
+// median so a single slow request, such as the first while brian warms up, is not taken for a regression.
+func medianLatency(t *testing.T, filename string, first time.Duration) time.Duration {
+	latencies := []time.Duration{first}
+	for len(latencies) < *perfSamples {
+		body, contentType, err := getCSDBRequestBody(filename)
+		require.Nil(t, err, Err("error creating POST request"))
+
+		start := time.Now()
+		response, err := postCSDBFile(body, contentType)
+		require.Nil(t, err, Err("error sending POST request"))
+		_, err = ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		require.Nil(t, err, Err("error reading csdb response"))
+		latencies = append(latencies, time.Since(start))
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return perf.Percentile(latencies, 50)
+}
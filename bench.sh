@@ -0,0 +1,20 @@
+#!/usr/bin/env bash
+#
+# Runs the ConvertCSDB benchmarks against brian and stores the results in resources/benchmarks/<label>.txt so runs
+# against different brian builds can be compared with benchstat:
+#
+#   ./bench.sh before
+#   ./bench.sh after
+#   benchstat resources/benchmarks/before.txt resources/benchmarks/after.txt
+
+set -e
+
+if [ -z "$1" ]; then
+    echo "usage: $0 <label> [count]"
+    exit 2
+fi
+
+export BRIAN_HOST="${BRIAN_HOST:-http://localhost:8083}"
+
+mkdir -p resources/benchmarks
+go test -run '^$' -bench ConvertCSDB -benchmem -count "${2:-10}" | tee "resources/benchmarks/$1.txt"
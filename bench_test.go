@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// BenchmarkConvertCSDB posts each file to /Services/ConvertCSDB repeatedly. The request body is built once so only the
+// request itself is measured. Run it with -benchmem and -count and compare runs against different brian builds with
+// benchstat, see bench.sh.
+func BenchmarkConvertCSDB(b *testing.B) {
+	for _, filename := range csdbFilenames {
+		b.Run(filename, func(b *testing.B) {
+			benchmarkConvertCSDB(b, filename)
+		})
+	}
+}
+
+func benchmarkConvertCSDB(b *testing.B, filename string) {
+	if !exists(fmt.Sprintf("resources/inputs/%s.csdb", filename)) {
+		b.Skipf("input file %s.csdb does not exist", filename)
+	}
+
+	body, contentType, err := getCSDBRequestBody(filename)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var responseBytes int64
+	for i := 0; i < b.N; i++ {
+		response, err := postCSDBFile(bytes.NewReader(data), contentType)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		n, err := io.Copy(ioutil.Discard, response.Body)
+		response.Body.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if response.StatusCode != http.StatusOK {
+			b.Fatalf("incorrect http response status code for POST CSDB request: %d", response.StatusCode)
+		}
+		responseBytes += n
+	}
+
+	b.ReportMetric(float64(responseBytes)/float64(b.N), "resp-B/op")
+}
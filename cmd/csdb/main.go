@@ -0,0 +1,138 @@
+// Command csdb provides tools for working with CSDB files before they are uploaded to project-brian.
+//
+// Usage:
+//
+//	csdb lint [-json] [-strict] file.csdb...
+//
+// lint exits with 0 if every file is valid, 1 if any file has errors (or warnings with -strict) and 2 if the
+// command could not be run.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ONSdigital/project-brian-api-test/csdb"
+)
+
+const (
+	exitOK = iota
+	exitInvalid
+	exitUsage
+)
+
+type fileResult struct {
+	File        string            `json:"file"`
+	Valid       bool              `json:"valid"`
+	Errors      int               `json:"errors"`
+	Warnings    int               `json:"warnings"`
+	Diagnostics []csdb.Diagnostic `json:"diagnostics"`
+	Failure     string            `json:"failure,omitempty"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+
+	switch os.Args[1] {
+	case "lint":
+		os.Exit(lint(os.Args[2:], os.Stdout, os.Stderr))
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: csdb <command> [arguments]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  lint [-json] [-strict] file.csdb...    validate the structure of CSDB files")
+}
+
+func lint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "write the results as JSON")
+	strict := flags.Bool("strict", false, "treat warnings as errors")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "usage: csdb lint [-json] [-strict] file.csdb...")
+		return exitUsage
+	}
+
+	results := make([]fileResult, 0, flags.NArg())
+	code := exitOK
+	for _, filename := range flags.Args() {
+		result := lintFile(filename)
+		if result.Failure != "" {
+			code = exitUsage
+		} else if !result.Valid || (*strict && result.Warnings > 0) {
+			result.Valid = false
+			if code == exitOK {
+				code = exitInvalid
+			}
+		}
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		return code
+	}
+
+	for _, result := range results {
+		if result.Failure != "" {
+			fmt.Fprintf(stderr, "%s: %s\n", result.File, result.Failure)
+			continue
+		}
+		for _, d := range result.Diagnostics {
+			fmt.Fprintf(stdout, "%s:%s\n", result.File, d)
+		}
+		fmt.Fprintf(stdout, "%s: %d errors, %d warnings\n", result.File, result.Errors, result.Warnings)
+	}
+	return code
+}
+
+func lintFile(filename string) fileResult {
+	result := fileResult{File: filename, Diagnostics: []csdb.Diagnostic{}}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+	defer f.Close()
+
+	diags, err := csdb.Lint(f)
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+
+	result.Diagnostics = append(result.Diagnostics, diags...)
+	for _, d := range diags {
+		if d.Severity == csdb.SeverityError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+	result.Valid = result.Errors == 0
+	return result
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// concurrentCopies is the number of simultaneous uploads of each fixture sent by TestConvert_ConcurrentUploads.
+const concurrentCopies = 4
+
+var concurrentFilenames = []string{
+	"ott",
+	"berd",
+	"sppi",
+}
+
+type concurrentResult struct {
+	filename string
+	copy     int
+	actual   []TimeSeries
+	err      error
+}
+
+func TestConvert_ConcurrentUploads(t *testing.T) {
+	Scenario(t, "Simultaneous conversions of different files do not affect each other")
+
+	Given(t, fmt.Sprintf("%d copies of each of %v", concurrentCopies, concurrentFilenames))
+	inputs := make(map[string][]byte)
+	for _, filename := range concurrentFilenames {
+		b, err := ioutil.ReadFile(fmt.Sprintf("resources/inputs/%s.csdb", filename))
+		require.Nil(t, err, Err("error reading input file"))
+		inputs[filename] = b
+	}
+
+	When(t, "they are all posted to /Services/ConvertCSDB at the same time")
+	start := make(chan struct{})
+	results := make(chan concurrentResult, len(concurrentFilenames)*concurrentCopies)
+
+	var wg sync.WaitGroup
+	for _, filename := range concurrentFilenames {
+		for i := 0; i < concurrentCopies; i++ {
+			wg.Add(1)
+			go func(filename string, copyIndex int) {
+				defer wg.Done()
+				<-start
+				actual, err := convertCSDB(filename+".csdb", inputs[filename])
+				results <- concurrentResult{filename: filename, copy: copyIndex, actual: actual, err: err}
+			}(filename, i)
+		}
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	Then(t, "every response contains only its own file's CDIDs and sourceDataset and matches the expected output")
+	for result := range results {
+		t.Run(fmt.Sprintf("%s.csdb#%d", result.filename, result.copy), func(t *testing.T) {
+			require.Nil(t, result.err, Err("error converting file"))
+			checkConcurrentResult(t, result)
+		})
+	}
+}
+
+func checkConcurrentResult(t *testing.T, result concurrentResult) {
+	file := readCSDBFixture(t, result.filename)
+	cdids := make(map[string]bool)
+	for _, s := range file.Series {
+		cdids[s.CDID()] = true
+	}
+
+	expected, err := getExpectedResults(result.filename)
+	require.Nil(t, err, Err("error reading expected csdb json file"))
+	require.NotEmpty(t, expected, Err("expected csdb json file contains no time series"))
+	sourceDatasets := expected[0].SourceDatasets
+
+	for i, ts := range result.actual {
+		require.True(t, cdids[ts.Description.CDID], Err(fmt.Sprintf("timeseries[%d] has CDID %q which is not in %s.csdb", i, ts.Description.CDID, result.filename)))
+		require.Equal(t, sourceDatasets, ts.SourceDatasets, Err(fmt.Sprintf("timeseries[%d].sourceDatasets did not match", i)))
+
+		for fieldName, values := range map[string][]TimeSeriesValue{"years": ts.Years, "quarters": ts.Quarters, "months": ts.Months} {
+			for j, v := range values {
+				if len(sourceDatasets) > 0 && v.SourceDataset != sourceDatasets[0] {
+					t.Fatalf(Err("timeseries[%d].%s[%d].sourceDataset: expected %q, got %q"), i, fieldName, j, sourceDatasets[0], v.SourceDataset)
+				}
+			}
+		}
+	}
+
+	require.Equal(t, len(expected), len(result.actual), Err("timeseries results length does not match expected"))
+	compareTimeSeries(t, result.filename, nil, result.actual, expected)
+}
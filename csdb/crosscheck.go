@@ -0,0 +1,161 @@
+package csdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
+)
+
+// Discrepancy is a difference between a converted time series and the CSDB series it was converted from.
+type Discrepancy struct {
+	// Series is the index of the converted time series, -1 for a CSDB series missing from the conversion.
+	Series int
+	// Location of the field in the converted series, e.g. months[3].value, or of the CSDB series' 92 record.
+	Location string
+	Message  string
+}
+
+func (d Discrepancy) String() string {
+	return d.Location + ": " + d.Message
+}
+
+var periodicityFields = []struct {
+	field string
+	code  string
+}{{"years", "A"}, {"quarters", "Q"}, {"months", "M"}}
+
+// CrossCheck uses the CSDB file as the oracle for its conversion. The years, quarters and months of each converted
+// series must each come from the 92/93/96/97 block of the same CDID and periodicity: the CDID is the 92 identifier,
+// the title is the 93 title of one of its blocks, the periods start at the 96 start period and there is one for each
+// 97 value, equal to it. Every block of the file must be in the conversion.
+func CrossCheck(f *File, series []timeseries.TimeSeries) []Discrepancy {
+	var discrepancies []Discrepancy
+	used := make(map[*Series]bool)
+	byCDID := make(map[string][]*Series)
+	for _, s := range f.Series {
+		byCDID[s.CDID()] = append(byCDID[s.CDID()], s)
+	}
+
+	for i, ts := range series {
+		discrepancy := func(location, format string, args ...interface{}) {
+			discrepancies = append(discrepancies, Discrepancy{Series: i, Location: location, Message: fmt.Sprintf(format, args...)})
+		}
+
+		cdid := ts.Description.CDID
+		blocks := byCDID[cdid]
+		if len(blocks) == 0 {
+			discrepancy("description.cdid", "no 92 series key has the CDID %q", cdid)
+			continue
+		}
+
+		var titles []string
+		titleMatched := false
+		for _, p := range periodicityFields {
+			values := map[string][]timeseries.TimeSeriesValue{"years": ts.Years, "quarters": ts.Quarters, "months": ts.Months}[p.field]
+			if len(values) == 0 {
+				continue
+			}
+
+			block := findBlock(blocks, p.code, values, used)
+			if block == nil {
+				discrepancy(p.field, "no 92 series key %s%s has the %s", cdid, p.code, p.field)
+				continue
+			}
+			used[block] = true
+
+			title := decodeTitle(block.Title)
+			titles = append(titles, fmt.Sprintf("%q (%s)", title, block.ID))
+			titleMatched = titleMatched || title == ts.Description.Title
+
+			checkBlock(discrepancy, p.field, block, values)
+		}
+		if len(titles) > 0 && !titleMatched {
+			discrepancy("description.title", "%q is not the 93 title of its series, %s", ts.Description.Title, strings.Join(titles, " or "))
+		}
+	}
+
+	for _, s := range f.Series {
+		if !used[s] && s.Count > 0 {
+			discrepancies = append(discrepancies, Discrepancy{
+				Series:   -1,
+				Location: fmt.Sprintf("line %d", s.Line),
+				Message:  fmt.Sprintf("the %d values of series %s are not in any converted time series", s.Count, s.ID),
+			})
+		}
+	}
+	return discrepancies
+}
+
+// findBlock returns the unused block of the periodicity the values were converted from, preferring the block whose
+// values start on the same date when there are several, e.g. seasonally adjusted and not.
+func findBlock(blocks []*Series, periodicity string, values []timeseries.TimeSeriesValue, used map[*Series]bool) *Series {
+	var found *Series
+	for _, s := range blocks {
+		if s.Periodicity != periodicity || used[s] {
+			continue
+		}
+		if start, ok := startPeriod(s); ok && start.String() == values[0].Date {
+			return s
+		}
+		if found == nil {
+			found = s
+		}
+	}
+	return found
+}
+
+// checkBlock checks the converted values have the range and values of the CSDB block.
+func checkBlock(discrepancy func(location, format string, args ...interface{}), field string, block *Series, values []timeseries.TimeSeriesValue) {
+	start, ok := startPeriod(block)
+	if !ok {
+		discrepancy(field, "series %s has an invalid 96 range record", block.ID)
+		return
+	}
+
+	if len(values) != block.Count {
+		discrepancy(field, "%d %s, the 96 range record of %s has %d from %s", len(values), field, block.ID, block.Count, start)
+	}
+	for j, v := range values {
+		location := fmt.Sprintf("%s[%d]", field, j)
+		if want := start.Add(j).String(); v.Date != want {
+			discrepancy(location+".date", "%q, the 96 range record of %s starts at %s so expected %s", v.Date, block.ID, start, want)
+			return
+		}
+		if j < len(block.Values) && !sameValue(v.Value, block.Values[j]) {
+			discrepancy(location+".value", "%q, the 97 value %d of %s is %q", v.Value, j+1, block.ID, block.Values[j])
+		}
+	}
+}
+
+// startPeriod returns the period the 96 range record of the block starts at.
+func startPeriod(s *Series) (timeseries.Period, bool) {
+	p, ok := map[string]timeseries.Periodicity{"A": timeseries.Annual, "Q": timeseries.Quarterly, "M": timeseries.Monthly}[s.Periodicity]
+	if !ok || s.StartYear == 0 {
+		return timeseries.Period{}, false
+	}
+	start := timeseries.Period{Periodicity: p, Year: s.StartYear}
+	if p != timeseries.Annual {
+		start.Index = s.StartPeriod
+	}
+	return start, true
+}
+
+// sameValue returns true if the converted value is the same number as the 97 value, or both are blank.
+func sameValue(converted, csdb string) bool {
+	if converted == csdb {
+		return true
+	}
+	a, err := timeseries.ParseDecimal(converted)
+	if err != nil {
+		return false
+	}
+	b, err := timeseries.ParseDecimal(csdb)
+	return err == nil && a.Cmp(b) == 0
+}
+
+// decodeTitle returns the 93 title as brian reads it: as UTF-8, each byte that is not valid UTF-8 such as a Latin-1 £
+// becoming U+FFFD.
+func decodeTitle(title string) string {
+	return string([]rune(title))
+}
@@ -0,0 +1,83 @@
+package csdb
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// convertedCSDB is brian's conversion of validCSDB.
+func convertedCSDB() []timeseries.TimeSeries {
+	ts := timeseries.TimeSeries{
+		Type:           "timeseries",
+		SourceDatasets: []string{"OTT"},
+		Description:    timeseries.Description{CDID: "GMAA", Title: "OS visits to UK:All visits Thousands-NSA"},
+	}
+	for i, v := range []string{"12419", "11451", "11638"} {
+		year := strconv.Itoa(1980 + i)
+		ts.Years = append(ts.Years, timeseries.TimeSeriesValue{Date: year, Value: v, Year: year, SourceDataset: "OTT"})
+	}
+	for i, v := range []string{"2081", "3240", "4738", "2360", "1920", "3008", "4261", "2262", "2013"} {
+		year, quarter := strconv.Itoa(1980+i/4), "Q"+strconv.Itoa(i%4+1)
+		ts.Quarters = append(ts.Quarters, timeseries.TimeSeriesValue{Date: year + " " + quarter, Value: v, Year: year, Quarter: quarter, SourceDataset: "OTT"})
+	}
+	return []timeseries.TimeSeries{ts}
+}
+
+func crossCheck(t *testing.T, csdb string, series []timeseries.TimeSeries) []string {
+	f, err := Parse(strings.NewReader(csdb))
+	require.Nil(t, err)
+
+	var discrepancies []string
+	for _, d := range CrossCheck(f, series) {
+		discrepancies = append(discrepancies, d.String())
+	}
+	return discrepancies
+}
+
+func TestCrossCheck(t *testing.T) {
+	assert.Empty(t, crossCheck(t, validCSDB, convertedCSDB()))
+
+	series := convertedCSDB()
+	series[0].Quarters[0].Value = "2081.0"
+	assert.Empty(t, crossCheck(t, validCSDB, series), "values should be compared as numbers")
+
+	latin1 := strings.Replace(validCSDB, "93OS visits to UK:All visits Thousands-NSA", "93OS visits to UK:Earnings: \xa3 Millions-NSA    ", -1)
+	series = convertedCSDB()
+	series[0].Description.Title = "OS visits to UK:Earnings: \ufffd Millions-NSA"
+	assert.Empty(t, crossCheck(t, latin1, series), "titles should be read as UTF-8 as brian does")
+}
+
+func TestCrossCheck_Discrepancies(t *testing.T) {
+	series := convertedCSDB()
+	series[0].Description.Title = "OS visits"
+	series[0].Years = series[0].Years[:2]
+	series[0].Years[1].Value = "11452"
+	series[0].Quarters[0].Date = "1979 Q4"
+
+	unknown := series[0]
+	unknown.Description.CDID = "GMAB"
+	monthly := series[0]
+	monthly.Years, monthly.Quarters = nil, nil
+	monthly.Months = []timeseries.TimeSeriesValue{{Date: "1980 JAN", Value: "1"}}
+
+	assert.Equal(t, []string{
+		`years: 2 years, the 96 range record of GMAAAU has 3 from 1980`,
+		`years[1].value: "11452", the 97 value 2 of GMAAAU is "11451"`,
+		`quarters[0].date: "1979 Q4", the 96 range record of GMAAQU starts at 1980 Q1 so expected 1980 Q1`,
+		`description.title: "OS visits" is not the 93 title of its series, "OS visits to UK:All visits Thousands-NSA" (GMAAAU) or "OS visits to UK:All visits Thousands-NSA" (GMAAQU)`,
+		`description.cdid: no 92 series key has the CDID "GMAB"`,
+		`months: no 92 series key GMAAM has the months`,
+	}, crossCheck(t, validCSDB, append(series, unknown, monthly)))
+
+	assert.Equal(t, []string{
+		"line 9: the 9 values of series GMAAQU are not in any converted time series",
+	}, crossCheck(t, validCSDB, []timeseries.TimeSeries{{
+		Description: convertedCSDB()[0].Description,
+		Years:       convertedCSDB()[0].Years,
+	}}))
+}
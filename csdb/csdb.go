@@ -0,0 +1,529 @@
+// Package csdb reads the fixed-width CSDB files that project-brian converts into time series JSON.
+//
+// A CSDB file is a header record (type 0), a dictionary describing the series key (type 1 records) and then one
+// block per series:
+//
+//	92 series key   - identifier (CDID + periodicity + seasonal adjustment) followed by the other dictionary keys
+//	93 title
+//	96 range        - periodicity, start year/period, last updated date, value count and value layout
+//	97 values       - fixed-width value fields, several per line
+package csdb
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Record type prefixes.
+const (
+	RecordHeader     = " 0"
+	RecordDictionary = " 1"
+	RecordKey        = "92"
+	RecordTitle      = "93"
+	RecordRange      = "96"
+	RecordValues     = "97"
+)
+
+// Defaults used when a 96 record does not describe the layout of its 97 records.
+const (
+	DefaultValuesPerLine = 7
+	DefaultValueWidth    = 10
+)
+
+// Header is the type 0 record at the top of every CSDB file.
+type Header struct {
+	Line      int
+	Date      string
+	Dataset   string
+	KeyWidths []int
+}
+
+// KeyWidth returns the total width of the series key described by the header.
+func (h Header) KeyWidth() int {
+	total := 0
+	for _, w := range h.KeyWidths {
+		total += w
+	}
+	return total
+}
+
+// DictionaryEntry is a type 1 record naming one field of the series key.
+type DictionaryEntry struct {
+	Line  int
+	Index int
+	Name  string
+}
+
+// Series is a single 92/93/96/97 block.
+type Series struct {
+	// ID is the identifier from the 92 record, e.g. GMAAAU: the CDID, periodicity and seasonal adjustment.
+	ID   string
+	Keys []string
+	Line int
+
+	Title string
+
+	Periodicity   string
+	DataType      string
+	StartYear     int
+	StartPeriod   int
+	Updated       string
+	Count         int
+	ValuesPerLine int
+	ValueWidth    int
+	Decimals      int
+
+	// Values holds the trimmed 97 fields in order, missing values are empty strings.
+	Values []string
+
+	raw      []string
+	valuesAt int
+}
+
+// CDID returns the four character CDID of the series.
+func (s *Series) CDID() string {
+	if len(s.ID) < 4 {
+		return s.ID
+	}
+	return s.ID[:4]
+}
+
+// SeasonalAdjustment returns the seasonal adjustment code from the series identifier.
+func (s *Series) SeasonalAdjustment() string {
+	if len(s.ID) < 6 {
+		return ""
+	}
+	return s.ID[5:6]
+}
+
+// File is a parsed CSDB file.
+type File struct {
+	Header     Header
+	Dictionary []DictionaryEntry
+	Series     []*Series
+
+	prelude []string
+	newline string
+}
+
+// Parse reads a CSDB file, returning an error for the first problem Lint would report as an error.
+func Parse(r io.Reader) (*File, error) {
+	f, diags, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return nil, errors.New(d.String())
+		}
+	}
+	return f, nil
+}
+
+type parser struct {
+	file    *File
+	diags   []Diagnostic
+	state   int
+	current *Series
+	seen    map[string]int
+}
+
+const (
+	expectHeader = iota
+	expectDictionary
+	expectKey
+	expectTitle
+	expectRange
+	expectValues
+)
+
+var expectedRecord = map[int]string{
+	expectHeader:     "header (0)",
+	expectDictionary: "dictionary (1)",
+	expectKey:        "series key (92)",
+	expectTitle:      "title (93)",
+	expectRange:      "range (96)",
+	expectValues:     "values (97)",
+}
+
+func parse(r io.Reader) (*File, []Diagnostic, error) {
+	p := &parser{file: &File{newline: "\n"}, seen: make(map[string]int)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanLines)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		text := scanner.Text()
+		if lineNum == 1 && strings.HasSuffix(text, "\r") {
+			p.file.newline = "\r\n"
+		}
+		line := strings.TrimRight(text, "\r")
+		p.line(lineNum, line)
+		if p.current != nil {
+			p.current.raw = append(p.current.raw, line)
+		} else {
+			p.file.prelude = append(p.file.prelude, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "error reading csdb file")
+	}
+	p.end(lineNum + 1)
+	return p.file, p.diags, nil
+}
+
+// scanLines splits on \n like bufio.ScanLines but keeps any \r so the original line endings can be written back.
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (p *parser) report(sev Severity, code string, line, col int, format string, args ...interface{}) {
+	p.diags = append(p.diags, newDiagnostic(sev, code, line, col, format, args...))
+}
+
+func (p *parser) line(n int, line string) {
+	if len(line) == 0 {
+		p.report(SeverityWarning, CodeWidth, n, 1, "blank line")
+		return
+	}
+	if len(line) < 2 {
+		p.report(SeverityError, CodeUnknownRecord, n, 1, "unknown record type %q", line)
+		return
+	}
+
+	switch line[:2] {
+	case RecordHeader:
+		p.header(n, line)
+	case RecordDictionary:
+		p.dictionary(n, line)
+	case RecordKey:
+		p.key(n, line)
+	case RecordTitle:
+		p.title(n, line)
+	case RecordRange:
+		p.rangeRecord(n, line)
+	case RecordValues:
+		p.values(n, line)
+	default:
+		p.report(SeverityError, CodeUnknownRecord, n, 1, "unknown record type %q", line[:2])
+	}
+}
+
+func (p *parser) unexpected(n int, record string) {
+	p.report(SeverityError, CodeOrder, n, 1, "unexpected %s record, expected %s", record, expectedRecord[p.state])
+}
+
+func (p *parser) header(n int, line string) {
+	if p.state != expectHeader {
+		p.unexpected(n, "header (0)")
+		return
+	}
+	p.state = expectDictionary
+
+	h := Header{Line: n}
+	if len(line) < 24 {
+		p.report(SeverityError, CodeWidth, n, len(line)+1, "header record is %d characters, expected at least 24", len(line))
+		p.file.Header = h
+		return
+	}
+	h.Date = line[2:10]
+	h.Dataset = strings.TrimSpace(line[10:22])
+
+	keyCount, ok := p.number(n, line, 22, 24, "dictionary key count")
+	if ok {
+		if want := 24 + keyCount*2; len(line) < want {
+			p.report(SeverityError, CodeWidth, n, len(line)+1, "header declares %d key widths but is %d characters, expected %d", keyCount, len(line), want)
+		} else {
+			for i := 0; i < keyCount; i++ {
+				start := 24 + i*2
+				w, ok := p.number(n, line, start, start+2, "key width")
+				if !ok {
+					w = 0
+				}
+				h.KeyWidths = append(h.KeyWidths, w)
+			}
+		}
+	}
+	p.file.Header = h
+}
+
+func (p *parser) dictionary(n int, line string) {
+	if p.state != expectDictionary {
+		p.unexpected(n, "dictionary (1)")
+		return
+	}
+
+	entry := DictionaryEntry{Line: n}
+	if len(line) < 4 {
+		p.report(SeverityError, CodeWidth, n, len(line)+1, "dictionary record is %d characters, expected at least 4", len(line))
+	} else {
+		if index, ok := p.number(n, line, 2, 4, "dictionary index"); ok {
+			entry.Index = index
+			if want := len(p.file.Dictionary) + 1; index != want {
+				p.report(SeverityError, CodeHeader, n, 3, "dictionary index %d out of sequence, expected %d", index, want)
+			}
+		}
+		entry.Name = strings.TrimSpace(line[4:])
+	}
+	p.file.Dictionary = append(p.file.Dictionary, entry)
+}
+
+// checkDictionary is called once the first series key is seen and verifies the dictionary against the header.
+func (p *parser) checkDictionary(n int) {
+	h := p.file.Header
+	if len(p.file.Dictionary) != len(h.KeyWidths) {
+		line := h.Line
+		if line == 0 {
+			line = n
+		}
+		p.report(SeverityError, CodeHeader, line, 23, "header declares %d dictionary keys but %d dictionary records were found", len(h.KeyWidths), len(p.file.Dictionary))
+	}
+	if len(h.KeyWidths) < 3 {
+		return
+	}
+	if h.KeyWidths[0] != 4 || h.KeyWidths[1] != 1 || h.KeyWidths[2] != 1 {
+		p.report(SeverityError, CodeHeader, h.Line, 25, "identifier, periodicity and seasonal adjustment keys must be 4, 1 and 1 characters wide, got %d, %d and %d", h.KeyWidths[0], h.KeyWidths[1], h.KeyWidths[2])
+	}
+}
+
+func (p *parser) key(n int, line string) {
+	switch p.state {
+	case expectDictionary:
+		p.checkDictionary(n)
+	case expectKey:
+	case expectTitle, expectRange, expectValues:
+		p.endSeries(n)
+	default:
+		p.unexpected(n, "series key (92)")
+	}
+	p.state = expectTitle
+
+	s := &Series{Line: n, ValuesPerLine: DefaultValuesPerLine, ValueWidth: DefaultValueWidth}
+	p.current = s
+	p.file.Series = append(p.file.Series, s)
+
+	widths := p.file.Header.KeyWidths
+	if len(widths) < 3 {
+		widths = []int{4, 1, 1}
+	}
+	keyWidth := 0
+	for _, w := range widths {
+		keyWidth += w
+	}
+	if len(line) < 2+keyWidth {
+		p.report(SeverityError, CodeWidth, n, len(line)+1, "series key record is %d characters, expected at least %d", len(line), 2+keyWidth)
+	}
+
+	pos := 2
+	for _, w := range widths {
+		end := pos + w
+		if end > len(line) {
+			end = len(line)
+		}
+		if pos > end {
+			pos = end
+		}
+		s.Keys = append(s.Keys, line[pos:end])
+		pos += w
+	}
+	s.ID = s.Keys[0] + s.Keys[1] + s.Keys[2]
+
+	if len(s.ID) != 6 || strings.TrimSpace(s.Keys[0]) != s.Keys[0] || s.Keys[0] == "" {
+		p.report(SeverityError, CodeWidth, n, 3, "series identifier %q is not a four character CDID followed by periodicity and seasonal adjustment", s.ID)
+		return
+	}
+	if !validPeriodicity(s.Keys[1]) {
+		p.report(SeverityError, CodeWidth, n, 7, "unknown periodicity %q in series identifier %q, expected A, Q or M", s.Keys[1], s.ID)
+	}
+
+	if first, ok := p.seen[s.ID]; ok {
+		p.report(SeverityError, CodeDuplicateCDID, n, 3, "duplicate series identifier %q, first defined on line %d", s.ID, first)
+		return
+	}
+	p.seen[s.ID] = n
+}
+
+func (p *parser) title(n int, line string) {
+	if p.state != expectTitle {
+		p.unexpected(n, "title (93)")
+		return
+	}
+	p.state = expectRange
+	p.current.Title = strings.TrimSpace(line[2:])
+	if p.current.Title == "" {
+		p.report(SeverityWarning, CodeWidth, n, 3, "series %q has an empty title", p.current.ID)
+	}
+}
+
+func (p *parser) rangeRecord(n int, line string) {
+	if p.state != expectRange {
+		p.unexpected(n, "range (96)")
+		return
+	}
+	p.state = expectValues
+	p.current.valuesAt = len(p.current.raw) + 1
+
+	s := p.current
+	if len(line) < 24 {
+		p.report(SeverityError, CodeWidth, n, len(line)+1, "range record is %d characters, expected at least 24", len(line))
+		return
+	}
+
+	s.Periodicity = line[2:3]
+	s.DataType = line[3:4]
+	if !validPeriodicity(s.Periodicity) {
+		p.report(SeverityError, CodeWidth, n, 3, "unknown periodicity %q, expected A, Q or M", s.Periodicity)
+	} else if len(s.ID) == 6 && s.Periodicity != s.Keys[1] {
+		p.report(SeverityError, CodeHeader, n, 3, "periodicity %q does not match series identifier %q", s.Periodicity, s.ID)
+	}
+
+	s.StartYear, _ = p.number(n, line, 4, 8, "start year")
+	if start, ok := p.number(n, line, 8, 11, "start period"); ok {
+		s.StartPeriod = start
+		if max := PeriodsPerYear(s.Periodicity); max > 0 && (start < 1 || start > max) {
+			p.report(SeverityError, CodeWidth, n, 9, "start period %d out of range 1-%d for periodicity %q", start, max, s.Periodicity)
+		}
+	}
+	s.Updated = line[11:19]
+	s.Count, _ = p.number(n, line, 19, 24, "value count")
+
+	if len(line) < 42 {
+		return
+	}
+	if perLine, ok := p.number(n, line, 37, 38, "values per line"); ok && perLine > 0 {
+		s.ValuesPerLine = perLine
+	}
+	if width, ok := p.number(n, line, 38, 40, "value width"); ok && width > 0 {
+		s.ValueWidth = width
+	}
+	s.Decimals, _ = p.number(n, line, 40, 42, "decimal places")
+}
+
+func (p *parser) values(n int, line string) {
+	if p.state != expectValues {
+		p.unexpected(n, "values (97)")
+		return
+	}
+	s := p.current
+
+	body := line[2:]
+	width := s.ValueWidth
+	if len(body)%width != 0 {
+		p.report(SeverityError, CodeWidth, n, len(line)+1, "values record is %d characters, expected 2 plus a multiple of the %d character value width", len(line), width)
+	}
+
+	fields := (len(body) + width - 1) / width
+	if fields > s.ValuesPerLine {
+		p.report(SeverityError, CodeWidth, n, 3+s.ValuesPerLine*width, "values record contains %d values, expected at most %d", fields, s.ValuesPerLine)
+	}
+	if fields < s.ValuesPerLine && len(s.Values)+fields < s.Count {
+		p.report(SeverityWarning, CodeWidth, n, len(line)+1, "short values record with %d values is not the last record of series %q", fields, s.ID)
+	}
+
+	for i := 0; i < fields; i++ {
+		start := i * width
+		end := start + width
+		if end > len(body) {
+			end = len(body)
+		}
+		raw := body[start:end]
+		value := strings.TrimSpace(raw)
+		col := 3 + start
+		if value != "" {
+			if strings.TrimLeft(raw, " ") != value {
+				p.report(SeverityError, CodeWidth, n, col, "value %q is not right aligned in its %d character field", raw, width)
+			}
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				p.report(SeverityError, CodeValue, n, col, "value %q is not a number", value)
+			} else if d := decimals(value); s.Decimals > 0 && d != s.Decimals {
+				p.report(SeverityWarning, CodeValue, n, col, "value %q has %d decimal places, range record declares %d", value, d, s.Decimals)
+			}
+		}
+		s.Values = append(s.Values, value)
+	}
+}
+
+// endSeries checks the series that has just finished. next is the line that ended it.
+func (p *parser) endSeries(next int) {
+	s := p.current
+	if s == nil {
+		return
+	}
+	p.current = nil
+
+	switch p.state {
+	case expectTitle, expectRange:
+		p.report(SeverityError, CodeOrder, next, 1, "series %q on line %d ended before its %s record", s.ID, s.Line, expectedRecord[p.state])
+		return
+	}
+	if len(s.Values) != s.Count {
+		p.report(SeverityError, CodeCount, s.Line+2, 20, "series %q declares %d values but its 97 records contain %d", s.ID, s.Count, len(s.Values))
+	}
+}
+
+func (p *parser) end(n int) {
+	switch p.state {
+	case expectHeader:
+		p.report(SeverityError, CodeOrder, n, 1, "file is empty, expected header (0)")
+	case expectDictionary:
+		p.checkDictionary(n)
+		p.report(SeverityError, CodeOrder, n, 1, "file contains no series")
+	default:
+		p.endSeries(n)
+	}
+}
+
+// number parses the right aligned integer in line[start:end], reporting a diagnostic if it is not valid.
+func (p *parser) number(n int, line string, start, end int, name string) (int, bool) {
+	if end > len(line) {
+		p.report(SeverityError, CodeWidth, n, len(line)+1, "%s at columns %d-%d is missing", name, start+1, end)
+		return 0, false
+	}
+	raw := line[start:end]
+	v, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		p.report(SeverityError, CodeWidth, n, start+1, "%s %q at columns %d-%d is not a number", name, raw, start+1, end)
+		return 0, false
+	}
+	return v, true
+}
+
+func validPeriodicity(p string) bool {
+	return PeriodsPerYear(p) > 0
+}
+
+// PeriodsPerYear returns the number of periods in a year for a periodicity code, or 0 if the code is unknown.
+func PeriodsPerYear(periodicity string) int {
+	switch periodicity {
+	case "A":
+		return 1
+	case "Q":
+		return 4
+	case "M":
+		return 12
+	}
+	return 0
+}
+
+func decimals(value string) int {
+	i := strings.IndexByte(value, '.')
+	if i < 0 {
+		return 0
+	}
+	return len(value) - i - 1
+}
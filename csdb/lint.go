@@ -0,0 +1,74 @@
+package csdb
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Severity of a lint diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic codes reported by Lint.
+const (
+	CodeOrder         = "record-order"
+	CodeCount         = "value-count"
+	CodeWidth         = "column-width"
+	CodeValue         = "value-format"
+	CodeUnknownRecord = "unknown-record"
+	CodeDuplicateCDID = "duplicate-cdid"
+	CodeHeader        = "header-dictionary"
+)
+
+// Diagnostic is a single problem found in a CSDB file. Line and Column are 1-based.
+type Diagnostic struct {
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+}
+
+func newDiagnostic(sev Severity, code string, line, col int, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{
+		Line:     line,
+		Column:   col,
+		Severity: sev,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s [%s]", d.Line, d.Column, d.Severity, d.Message, d.Code)
+}
+
+// Lint validates the structure of a CSDB file and returns every problem found, ordered by position.
+func Lint(r io.Reader) ([]Diagnostic, error) {
+	_, diags, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Column < diags[j].Column
+	})
+	return diags, nil
+}
+
+// HasErrors returns true if any of the diagnostics is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package csdb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validCSDB = ` 02016 219OTT          3 4 1 1
+ 1 1IDENTIFIER
+ 1 2PERIODICITY
+ 1 3SEASONAL ADJUSTMENT
+92GMAAAU
+93OS visits to UK:All visits Thousands-NSA
+96AS1980  12015 818    3             710 0
+97     12419     11451     11638
+92GMAAQU
+93OS visits to UK:All visits Thousands-NSA
+96QS1980  12016 120    9             710 0
+97      2081      3240      4738      2360      1920      3008      4261
+97      2262      2013
+`
+
+func lines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func withLine(n int, replacement string) string {
+	l := lines(validCSDB)
+	l[n-1] = replacement
+	return strings.Join(l, "\n") + "\n"
+}
+
+func TestLint_Fixtures(t *testing.T) {
+	for _, name := range []string{"ott", "bb", "berd", "ragv", "sppi"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(fmt.Sprintf("../resources/inputs/%s.csdb", name))
+			require.Nil(t, err)
+			defer f.Close()
+
+			diags, err := Lint(f)
+			require.Nil(t, err)
+			assert.Empty(t, diags)
+		})
+	}
+}
+
+func TestLint_Valid(t *testing.T) {
+	diags, err := Lint(strings.NewReader(validCSDB))
+	require.Nil(t, err)
+	assert.Empty(t, diags)
+
+	file, err := Parse(strings.NewReader(validCSDB))
+	require.Nil(t, err)
+	require.Len(t, file.Series, 2)
+	assert.Equal(t, "GMAA", file.Series[1].CDID())
+	assert.Equal(t, "Q", file.Series[1].Periodicity)
+	assert.Equal(t, 9, file.Series[1].Count)
+	assert.Equal(t, "2013", file.Series[1].Values[8])
+}
+
+func TestLint_Diagnostics(t *testing.T) {
+	scenarios := []struct {
+		name   string
+		input  string
+		code   string
+		line   int
+		column int
+	}{
+		{"unknown record", withLine(8, "98     12419"), CodeUnknownRecord, 8, 1},
+		{"title before key", withLine(9, "93OS visits"), CodeOrder, 9, 1},
+		{"too few values", withLine(7, "96AS1980  12015 818    4             710 0"), CodeCount, 7, 20},
+		{"too many values", withLine(11, "96QS1980  12016 120    8             710 0"), CodeCount, 11, 20},
+		{"misaligned value", withLine(8, "97     12419     11451    11638 "), CodeWidth, 8, 23},
+		{"truncated values", withLine(8, "97     12419     11451     1163"), CodeWidth, 8, 32},
+		{"not a number", withLine(8, "97     12419     11451      1x38"), CodeValue, 8, 23},
+		{"duplicate identifier", withLine(9, "92GMAAAU"), CodeDuplicateCDID, 9, 3},
+		{"periodicity mismatch", withLine(11, "96MS1980  12016 120    9             710 0"), CodeHeader, 11, 3},
+		{"dictionary count", withLine(1, " 02016 219OTT          4 4 1 1 4"), CodeHeader, 1, 23},
+		{"dictionary sequence", withLine(3, " 1 3PERIODICITY"), CodeHeader, 3, 3},
+		{"start period", withLine(11, "96QS1980  52016 120    9             710 0"), CodeWidth, 11, 9},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			diags, err := Lint(strings.NewReader(s.input))
+			require.Nil(t, err)
+			require.True(t, HasErrors(diags), "expected errors, got %v", diags)
+
+			found := false
+			for _, d := range diags {
+				if d.Code == s.code && d.Line == s.line && d.Column == s.column {
+					found = true
+				}
+			}
+			assert.True(t, found, "expected %s at %d:%d, got %v", s.code, s.line, s.column, diags)
+
+			_, err = Parse(strings.NewReader(s.input))
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package csdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
+)
+
+// CheckMerging checks how the blocks of the file were merged into time series. The blocks of a CDID with the same
+// seasonal adjustment, e.g. GMAAAU and GMAAQU, must all be in one series, each in the years, quarters or months of its
+// periodicity. Blocks with different seasonal adjustments, e.g. GMATAU and GMATQA, must never be in the same series.
+// A block is found in the conversion by its values, so blocks whose values were not converted correctly are left to
+// CrossCheck.
+func CheckMerging(f *File, series []timeseries.TimeSeries) []Discrepancy {
+	var discrepancies []Discrepancy
+	byCDID := make(map[string][]*Series)
+	for _, s := range f.Series {
+		byCDID[s.CDID()] = append(byCDID[s.CDID()], s)
+	}
+
+	// groups are the indexes of the series holding the blocks of each CDID and seasonal adjustment, in order.
+	type group struct{ cdid, adjustment string }
+	groups := make(map[group][]int)
+	var groupOrder []group
+
+	for i, ts := range series {
+		discrepancy := func(location, format string, args ...interface{}) {
+			discrepancies = append(discrepancies, Discrepancy{Series: i, Location: location, Message: fmt.Sprintf(format, args...)})
+		}
+
+		var merged []string
+		adjustments := make(map[string]bool)
+		for _, p := range periodicityFields {
+			values := map[string][]timeseries.TimeSeriesValue{"years": ts.Years, "quarters": ts.Quarters, "months": ts.Months}[p.field]
+			if len(values) == 0 {
+				continue
+			}
+			block := sourceBlock(byCDID[ts.Description.CDID], p.code, values)
+			if block == nil {
+				continue
+			}
+
+			if block.Periodicity != p.code {
+				discrepancy(p.field, "holds the values of %s, which belong in the %s", block.ID, fieldOf(block.Periodicity))
+			}
+			merged = append(merged, block.ID)
+			adjustments[block.SeasonalAdjustment()] = true
+
+			g := group{block.CDID(), block.SeasonalAdjustment()}
+			if _, ok := groups[g]; !ok {
+				groupOrder = append(groupOrder, g)
+			}
+			if indexes := groups[g]; len(indexes) == 0 || indexes[len(indexes)-1] != i {
+				groups[g] = append(indexes, i)
+			}
+		}
+
+		if len(adjustments) > 1 {
+			discrepancy("description.cdid", "merges blocks with different seasonal adjustments: %s", strings.Join(merged, ", "))
+		}
+	}
+
+	for _, g := range groupOrder {
+		indexes := groups[g]
+		if len(indexes) < 2 {
+			continue
+		}
+		locations := make([]string, len(indexes))
+		for j, i := range indexes {
+			locations[j] = fmt.Sprintf("timeseries[%d]", i)
+		}
+		discrepancies = append(discrepancies, Discrepancy{
+			Series:   indexes[1],
+			Location: "description.cdid",
+			Message: fmt.Sprintf("the blocks of %s with seasonal adjustment %s are split across %s, they should be merged into one series",
+				g.cdid, g.adjustment, strings.Join(locations, " and ")),
+		})
+	}
+	return discrepancies
+}
+
+// sourceBlock returns the block of the CDID with the values, preferring a block of the periodicity so a block in the
+// wrong array is still found, or nil if no block has the values.
+func sourceBlock(blocks []*Series, periodicity string, values []timeseries.TimeSeriesValue) *Series {
+	var found *Series
+	for _, s := range blocks {
+		if !hasValues(s, values) {
+			continue
+		}
+		if s.Periodicity == periodicity {
+			return s
+		}
+		if found == nil {
+			found = s
+		}
+	}
+	return found
+}
+
+func hasValues(s *Series, values []timeseries.TimeSeriesValue) bool {
+	if len(s.Values) != len(values) {
+		return false
+	}
+	for i, v := range values {
+		if !sameValue(v.Value, s.Values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldOf returns the field of the time series holding the values of a periodicity.
+func fieldOf(periodicity string) string {
+	for _, p := range periodicityFields {
+		if p.code == periodicity {
+			return p.field
+		}
+	}
+	return periodicity
+}
@@ -0,0 +1,50 @@
+package csdb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkMerging(t *testing.T, csdb string, series []timeseries.TimeSeries) []string {
+	f, err := Parse(strings.NewReader(csdb))
+	require.Nil(t, err)
+
+	var discrepancies []string
+	for _, d := range CheckMerging(f, series) {
+		discrepancies = append(discrepancies, d.String())
+	}
+	return discrepancies
+}
+
+func TestCheckMerging(t *testing.T) {
+	assert.Empty(t, checkMerging(t, validCSDB, convertedCSDB()))
+
+	seasonallyAdjusted := strings.Replace(validCSDB, "92GMAAQU", "92GMAAQA", 1)
+	years, quarters := convertedCSDB()[0], convertedCSDB()[0]
+	years.Quarters, quarters.Years = nil, nil
+	assert.Empty(t, checkMerging(t, seasonallyAdjusted, []timeseries.TimeSeries{years, quarters}),
+		"blocks with different seasonal adjustments should be separate series")
+}
+
+func TestCheckMerging_Discrepancies(t *testing.T) {
+	years, quarters := convertedCSDB()[0], convertedCSDB()[0]
+	years.Quarters, quarters.Years = nil, nil
+	assert.Equal(t, []string{
+		"description.cdid: the blocks of GMAA with seasonal adjustment U are split across timeseries[0] and timeseries[1], they should be merged into one series",
+	}, checkMerging(t, validCSDB, []timeseries.TimeSeries{years, quarters}))
+
+	seasonallyAdjusted := strings.Replace(validCSDB, "92GMAAQU", "92GMAAQA", 1)
+	assert.Equal(t, []string{
+		"description.cdid: merges blocks with different seasonal adjustments: GMAAAU, GMAAQA",
+	}, checkMerging(t, seasonallyAdjusted, convertedCSDB()))
+
+	misplaced := convertedCSDB()
+	misplaced[0].Months, misplaced[0].Quarters = misplaced[0].Quarters, nil
+	assert.Equal(t, []string{
+		"months: holds the values of GMAAQU, which belong in the quarters",
+	}, checkMerging(t, validCSDB, misplaced))
+}
@@ -0,0 +1,74 @@
+package csdb
+
+import (
+	"fmt"
+	"math"
+)
+
+const cdidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Scale returns a copy of the file with factor times as many CDIDs. A factor below 1 keeps the first CDIDs of the
+// file, above 1 the series are repeated under fresh CDIDs that are not used by the original file. All of the series
+// for a CDID are kept together.
+func (f *File) Scale(factor float64) (*File, error) {
+	groups := f.GroupByCDID()
+	want := int(math.Round(factor * float64(len(groups))))
+	if want < 1 {
+		return nil, fmt.Errorf("scale factor %v leaves no series", factor)
+	}
+
+	used := make(map[string]bool)
+	for _, s := range f.Series {
+		used[s.CDID()] = true
+	}
+	next := cdidGenerator(used)
+
+	var series []*Series
+	for i := 0; i < want; i++ {
+		group := groups[i%len(groups)]
+		if i < len(groups) {
+			series = append(series, group...)
+			continue
+		}
+
+		cdid, err := next()
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range group {
+			series = append(series, s.withCDID(cdid))
+		}
+	}
+	return f.WithSeries(series), nil
+}
+
+// withCDID returns a copy of the series with its 92 record rewritten to use cdid.
+func (s *Series) withCDID(cdid string) *Series {
+	clone := s.Clone()
+	clone.Keys[0] = cdid
+	clone.ID = cdid + s.ID[4:]
+	clone.raw[0] = clone.raw[0][:2] + cdid + clone.raw[0][6:]
+	return clone
+}
+
+// cdidGenerator returns a function producing unused four character CDIDs starting with a letter.
+func cdidGenerator(used map[string]bool) func() (string, error) {
+	n := 10 * 36 * 36 * 36
+	max := 36 * 36 * 36 * 36
+	return func() (string, error) {
+		for ; n < max; n++ {
+			code := []byte{
+				cdidAlphabet[n/(36*36*36)%36],
+				cdidAlphabet[n/(36*36)%36],
+				cdidAlphabet[n/36%36],
+				cdidAlphabet[n%36],
+			}
+			if !used[string(code)] {
+				used[string(code)] = true
+				n++
+				return string(code), nil
+			}
+		}
+		return "", fmt.Errorf("no unused CDIDs left")
+	}
+}
@@ -0,0 +1,32 @@
+package csdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScale(t *testing.T) {
+	file, err := Parse(bytes.NewReader([]byte(validCSDB)))
+	require.Nil(t, err)
+
+	for _, factor := range []float64{1, 2, 3.5} {
+		scaled, err := file.Scale(factor)
+		require.Nil(t, err)
+
+		diags, err := Lint(bytes.NewReader(scaled.Bytes()))
+		require.Nil(t, err)
+		assert.Empty(t, diags, "scaled file should be valid")
+
+		groups := scaled.GroupByCDID()
+		assert.Len(t, groups, int(factor+0.5))
+		for _, group := range groups {
+			assert.Len(t, group, 2, "series for a CDID should be kept together")
+		}
+	}
+
+	_, err = file.Scale(0.1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,100 @@
+package csdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTo writes the file in CSDB format, preserving the original records and line endings of anything that has
+// not been modified.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	write := func(line string) error {
+		n, err := io.WriteString(w, line+f.newline)
+		written += int64(n)
+		return err
+	}
+
+	for _, line := range f.prelude {
+		if err := write(line); err != nil {
+			return written, err
+		}
+	}
+	for _, s := range f.Series {
+		for _, line := range s.raw {
+			if err := write(line); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Bytes returns the file in CSDB format.
+func (f *File) Bytes() []byte {
+	var b bytes.Buffer
+	f.WriteTo(&b)
+	return b.Bytes()
+}
+
+// WithSeries returns a copy of the file with the same header and dictionary containing only the given series.
+func (f *File) WithSeries(series []*Series) *File {
+	clone := *f
+	clone.Series = append([]*Series(nil), series...)
+	return &clone
+}
+
+// GroupByCDID returns the series of the file grouped by CDID, in the order each CDID first appears.
+func (f *File) GroupByCDID() [][]*Series {
+	var groups [][]*Series
+	index := make(map[string]int)
+	for _, s := range f.Series {
+		i, ok := index[s.CDID()]
+		if !ok {
+			i = len(groups)
+			index[s.CDID()] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], s)
+	}
+	return groups
+}
+
+// Clone returns a deep copy of the series that can be modified without changing the original.
+func (s *Series) Clone() *Series {
+	clone := *s
+	clone.Keys = append([]string(nil), s.Keys...)
+	clone.Values = append([]string(nil), s.Values...)
+	clone.raw = append([]string(nil), s.raw...)
+	return &clone
+}
+
+// SetValue replaces the value at index i, rewriting the series' 97 records.
+func (s *Series) SetValue(i int, value string) error {
+	if i < 0 || i >= len(s.Values) {
+		return fmt.Errorf("value index %d out of range for series %q with %d values", i, s.ID, len(s.Values))
+	}
+	if len(value) > s.ValueWidth {
+		return fmt.Errorf("value %q is wider than the %d character value width", value, s.ValueWidth)
+	}
+	s.Values[i] = value
+
+	raw := append([]string(nil), s.raw[:s.valuesAt]...)
+	for start := 0; start < len(s.Values); start += s.ValuesPerLine {
+		end := start + s.ValuesPerLine
+		if end > len(s.Values) {
+			end = len(s.Values)
+		}
+
+		line := strings.Builder{}
+		line.WriteString(RecordValues)
+		for _, v := range s.Values[start:end] {
+			fmt.Fprintf(&line, "%*s", s.ValueWidth, v)
+		}
+		raw = append(raw, line.String())
+	}
+	s.raw = raw
+	return nil
+}
@@ -0,0 +1,43 @@
+package csdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTo_RoundTrip(t *testing.T) {
+	for _, name := range []string{"ott", "berd", "sppi"} {
+		t.Run(name, func(t *testing.T) {
+			b, err := ioutil.ReadFile(fmt.Sprintf("../resources/inputs/%s.csdb", name))
+			require.Nil(t, err)
+
+			file, err := Parse(bytes.NewReader(b))
+			require.Nil(t, err)
+			assert.True(t, bytes.Equal(b, file.Bytes()), "round trip of %s.csdb did not reproduce the original bytes", name)
+		})
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	file, err := Parse(strings.NewReader(validCSDB))
+	require.Nil(t, err)
+
+	series := file.Series[1].Clone()
+	require.Nil(t, series.SetValue(8, "9999"))
+	assert.NotNil(t, series.SetValue(9, "1"))
+	assert.NotNil(t, series.SetValue(0, "12345678901"))
+
+	modified := file.WithSeries([]*Series{file.Series[0], series})
+	reparsed, err := Parse(bytes.NewReader(modified.Bytes()))
+	require.Nil(t, err)
+
+	assert.Equal(t, "9999", reparsed.Series[1].Values[8])
+	assert.Equal(t, "2013", file.Series[1].Values[8], "original series should not be modified")
+	assert.Equal(t, file.Series[0].Values, reparsed.Series[0].Values)
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
-	. "github.com/logrusorgru/aurora"
+	"github.com/ONSdigital/project-brian-api-test/csdb"
+	"github.com/ONSdigital/project-brian-api-test/report"
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,6 +19,8 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -24,62 +29,63 @@ const (
 	descErrFmt = "timeseries[%d].description did not match the expected value"
 	typeErrFmt = "timmeseries[%d].type did not match the expected value"
 
+	yearsLenErrFmt    = "timeseries[%d].years length does not match expected"
 	monthsLenErrFmt   = "timmeseries[%d].months length did not match the expected length"
 	quartersLenErrFmt = "incorrect length timmeseries[%d].quarters"
 )
 
 var brianHost = "http://localhost:8083"
 
-type TimeSeriesValue struct {
-	Date          string `json:"date"`
-	Value         string `json:"value"`
-	Year          string `json:"year"`
-	Month         string `json:"month"`
-	Quarter       string `json:"quarter"`
-	SourceDataset string `json:"sourceDataset"`
-}
+// maxChangedValues is the number of changed values listed per series when a comparison fails.
+const maxChangedValues = 10
 
-type Description struct {
-	Title      string `json:"title"`
-	CDID       string `json:"cdid"`
-	Unit       string `json:"unit"`
-	PreUnit    string `json:"preUnit"`
-	Source     string `json:"source"`
-	Date       string `json:"date"`
-	Number     string `json:"number"`
-	SampleSize int    `json:"sampleSize"`
-}
+// brianVersion is the version of brian under test, recorded in the run summary.
+var brianVersion = ""
+
+var tolerancePath = flag.String("tolerance", "", "read the tolerance rules for comparing values from this JSON file, values must be equal if empty")
+
+// toleranceRules choose how the values of each series are compared, exactly if nil.
+var toleranceRules *timeseries.ToleranceRules
+
+var fieldsPath = flag.String("fields", "", "read the rules for ignoring, normalising or only checking the format of volatile fields from this JSON file")
+
+// fieldRules override the comparison of volatile fields, every field is compared if nil.
+var fieldRules *timeseries.FieldRules
 
-type TimeSeries struct {
-	Years          []TimeSeriesValue `json:"years"`
-	Quarters       []TimeSeriesValue `json:"quarters"`
-	Months         []TimeSeriesValue `json:"months"`
-	SourceDatasets []string          `json:"sourceDatasets"`
-	Section        interface{}       `json:"section"`
-	Type           string            `json:"type"`
-	Description    Description       `json:"description"`
+var aggregationPath = flag.String("aggregation", "", "check years and quarters agree with their quarters and months using the aggregation rules in this JSON file")
+
+// aggregationRules choose how the values of each series aggregate, the check is skipped if nil.
+var aggregationRules *timeseries.AggregationRules
+
+var csdbFilenames = []string{
+	"ott",
+	"bb",
+	"berd",
+	"ukea",
+	"ragv",
+	"sppi",
 }
 
-func TestConvert_CSDBToJSON(t *testing.T) {
+func init() {
 	host := os.Getenv("BRIAN_HOST")
 	if len(host) > 0 {
 		brianHost = host
 	}
+	brianVersion = os.Getenv("BRIAN_VERSION")
+}
 
+type (
+	TimeSeries      = timeseries.TimeSeries
+	TimeSeriesValue = timeseries.TimeSeriesValue
+	Description     = timeseries.Description
+)
+
+func TestConvert_CSDBToJSON(t *testing.T) {
 	info(t, fmt.Sprintf("\nTest config:\n\t%q:%q\n", "BRIAN_HOST", brianHost))
 
 	if !exists("resources/outputs") {
-		t.Errorf(Err(fmt.Sprintf("dir %q does not exist", "resources/outputs")))
-		t.Fatalf(Err(fmt.Sprintf("make sure you have unzipped %q before running the tests", "resources/outputs.zip")))
-	}
-
-	csdbFilenames := []string{
-		"ott",
-		"bb",
-		"berd",
-		"ukea",
-		"ragv",
-		"sppi",
+		t.Error(Err(fmt.Sprintf("dir %q does not exist", "resources/outputs")))
+		t.Fatal(Err(fmt.Sprintf("make sure you have unzipped %q before running the tests", "resources/outputs.zip")))
 	}
 
 	for _, filename := range csdbFilenames {
@@ -90,15 +96,22 @@ func TestConvert_CSDBToJSON(t *testing.T) {
 }
 
 func testCSDBJSONGeneration(t *testing.T, filename string) {
+	dataset := report.NewDataset(filename)
+	defer func() {
+		dataset.Finish(t.Failed(), t.Skipped())
+		emit(t, report.Event{Kind: report.EventDataset, Message: filename, Status: dataset.Status, Duration: dataset.Duration, Dataset: dataset})
+	}()
+
 	Scenario(t, fmt.Sprintf("The correct JSON is generated for a given %s.csdb file", filename))
 
 	Given(t, fmt.Sprintf("a valid %s.csdb file", filename))
 	body, contentType, err := getCSDBRequestBody(filename)
-	require.Nil(t, err, Red("error creating POST request").String())
+	requireNoError(t, dataset, err, "error creating POST request")
 
 	When(t, "a POST request is sent to /Services/ConvertCSDB")
+	start := time.Now()
 	response, err := postCSDBFile(body, contentType)
-	require.Nil(t, err, Err("error sending POST request"))
+	requireNoError(t, dataset, err, "error sending POST request")
 
 	defer func() {
 		if err := response.Body.Close(); err != nil {
@@ -107,61 +120,462 @@ func testCSDBJSONGeneration(t *testing.T, filename string) {
 	}()
 
 	Then(t, "a 200 response status is returned")
+	assertion(t, response.StatusCode == 200, fmt.Sprintf("response status code %d is 200", response.StatusCode))
+	if response.StatusCode != 200 {
+		dataset.Fail("incorrect http response status code for POST CSDB request: %d", response.StatusCode)
+	}
 	require.Equal(t, response.StatusCode, 200, Err("incorrect http response status code for POST CSDB request"))
 
-	actualTimeSeries, err := readCSDBResponse(response)
-	require.Nil(t, err, Err("error reading csdb response json"))
+	data, err := ioutil.ReadAll(response.Body)
+	requireNoError(t, dataset, err, "error reading csdb response")
+	latency := time.Since(start)
+	timing(t, "POST /Services/ConvertCSDB", latency)
 
+	actualTimeSeries, err := decodeCSDBResponse(data)
+	requireNoError(t, dataset, err, "error reading csdb response json")
+
+	// The series are recorded before the checks below so the report can show their failures against each series.
 	expectedTimeSeries, err := getExpectedResults(filename)
-	require.Nil(t, err, Err("error reading expected csdb json file"))
+	requireNoError(t, dataset, err, "error reading expected csdb json file")
+
+	for _, ts := range expectedTimeSeries {
+		dataset.Series = append(dataset.Series, report.Series{CDID: ts.Description.CDID, Title: ts.Description.Title, Unit: ts.Description.Unit})
+	}
+
+	And(t, "every time series satisfies the invariants")
+	checkInvariants(t, dataset, actualTimeSeries)
+
+	input := readCSDBFixture(t, filename)
+	And(t, "every time series agrees with the input CSDB file")
+	crossCheckInput(t, dataset, input, actualTimeSeries)
+
+	And(t, "the blocks of each CDID are merged by seasonal adjustment")
+	checkMerging(t, dataset, input, actualTimeSeries)
+
+	if aggregationRules != nil {
+		And(t, "the years and quarters agree with their quarters and months")
+		checkAggregation(t, filename, dataset, actualTimeSeries)
+	}
+
+	for _, ts := range actualTimeSeries {
+		dataset.ValueCount += len(ts.Years) + len(ts.Quarters) + len(ts.Months)
+	}
 
 	And(t, "the expected number of timeSeries results are returned")
+	assertion(t, len(expectedTimeSeries) == len(actualTimeSeries),
+		fmt.Sprintf("%d timeseries returned, expected %d", len(actualTimeSeries), len(expectedTimeSeries)))
+	if len(expectedTimeSeries) != len(actualTimeSeries) {
+		dataset.AddMismatches(report.Mismatch{
+			Series:   -1,
+			Location: "timeseries",
+			Fields:   []string{"timeseries"},
+			Reason:   fmt.Sprintf("timeseries results length does not match expected: expected %d, actual %d", len(expectedTimeSeries), len(actualTimeSeries)),
+		})
+	}
 	require.Equal(t, len(expectedTimeSeries), len(actualTimeSeries), Err("timeseries results length does not match expected"))
 
-	var actual TimeSeries
-	var expected TimeSeries
-
 	And(t, "each time series value is as expected")
+	compareTimeSeries(t, filename, dataset, actualTimeSeries, expectedTimeSeries)
 
-	for index := 0; index < len(actualTimeSeries); index++ {
-		actual = actualTimeSeries[index]
-		expected = expectedTimeSeries[index]
+	And(t, "the latency and response size have not regressed")
+	checkPerformanceBaseline(t, filename, latency, int64(len(data)))
+	info(t, "Passed")
+}
 
-		require.Equal(t, actual.Description, expected.Description, descErrFmt, index)
-		require.Equal(t, actual.Type, expected.Type, typeErrFmt, index)
-		require.Len(t, actual.Years, len(expected.Years), "timeseries[%d].years length does not match expected", index)
+// requireNoError records err against the dataset report before requiring it to be nil.
+func requireNoError(t *testing.T, dataset *report.Dataset, err error, message string) {
+	if err != nil {
+		dataset.Fail("%s: %s", message, err)
+	}
+	require.Nil(t, err, Err(message))
+}
 
-		for yearIndex := 0; yearIndex < len(actual.Years); yearIndex++ {
-			compareTimeSeriesValue(t, actual.Years[yearIndex], expected.Years[yearIndex], "actual did not match expected", "years", index, yearIndex)
+// checkInvariants requires every time series to satisfy the invariants that hold for any response, golden or not.
+// Every violation is recorded against the dataset report, if there is one, before the test fails.
+func checkInvariants(t *testing.T, dataset *report.Dataset, series []TimeSeries) {
+	t.Helper()
+	var violations []report.Mismatch
+	for i, ts := range series {
+		for _, v := range timeseries.CheckInvariants(ts) {
+			violations = append(violations, report.Mismatch{
+				Series:   i,
+				Location: fmt.Sprintf("timeseries[%d].%s", i, v.Location),
+				Fields:   []string{fieldName(v.Location)},
+				Reason:   fmt.Sprintf("%s breaks an invariant: %s", ts.Description.CDID, v.Message),
+			})
 		}
+	}
 
-		require.Len(t, actual.Months, len(expected.Months), monthsLenErrFmt, index)
-		for monthIndex := 0; monthIndex < len(actual.Months); monthIndex++ {
-			compareTimeSeriesValue(t, actual.Months[monthIndex], expected.Months[monthIndex], "actual did not match expected", "months", index, monthIndex)
+	requireNoViolations(t, dataset, "invariant violations", violations)
+}
+
+// crossCheckInput requires every time series to agree with the CSDB series it was converted from, using the input
+// file as the oracle. Every discrepancy is recorded against the dataset report, if there is one, before the test
+// fails.
+func crossCheckInput(t *testing.T, dataset *report.Dataset, input *csdb.File, series []TimeSeries) {
+	t.Helper()
+	var discrepancies []report.Mismatch
+	for _, d := range csdb.CrossCheck(input, series) {
+		m := report.Mismatch{Series: d.Series, Location: d.Location, Reason: d.Message}
+		if d.Series >= 0 {
+			m.Location = fmt.Sprintf("timeseries[%d].%s", d.Series, d.Location)
+			m.Fields = []string{fieldName(d.Location)}
+			m.Reason = fmt.Sprintf("%s does not agree with the input: %s", series[d.Series].Description.CDID, d.Message)
 		}
+		discrepancies = append(discrepancies, m)
+	}
+	requireNoViolations(t, dataset, "discrepancies with the input CSDB file", discrepancies)
+}
+
+// checkMerging requires the blocks of each CDID in the input file to be merged into one time series per seasonal
+// adjustment, each block in the years, quarters or months of its periodicity. Every discrepancy is recorded against
+// the dataset report, if there is one, before the test fails.
+func checkMerging(t *testing.T, dataset *report.Dataset, input *csdb.File, series []TimeSeries) {
+	t.Helper()
+	var discrepancies []report.Mismatch
+	for _, d := range csdb.CheckMerging(input, series) {
+		discrepancies = append(discrepancies, report.Mismatch{
+			Series:   d.Series,
+			Location: fmt.Sprintf("timeseries[%d].%s", d.Series, d.Location),
+			Fields:   []string{fieldName(d.Location)},
+			Reason:   fmt.Sprintf("%s is merged incorrectly: %s", series[d.Series].Description.CDID, d.Message),
+		})
+	}
+	requireNoViolations(t, dataset, "periodicity merging errors", discrepancies)
+}
 
-		require.Len(t, actual.Quarters, len(expected.Quarters), quartersLenErrFmt, index)
-		for quarterIndex := 0; quarterIndex < len(actual.Quarters); quarterIndex++ {
-			compareTimeSeriesValue(t, actual.Quarters[quarterIndex], expected.Quarters[quarterIndex], "actual did not match expected", "quarters", index, quarterIndex)
+// checkAggregation warns of every year or quarter of the named dataset that does not agree with its quarters or
+// months, recording them against the dataset report. These are data-quality warnings, the test does not fail.
+func checkAggregation(t *testing.T, name string, dataset *report.Dataset, series []TimeSeries) {
+	t.Helper()
+	var warnings []report.Mismatch
+	for i, ts := range series {
+		aggregation := aggregationRules.For(name, ts.Description.Unit, ts.Description.CDID)
+		for _, inconsistency := range timeseries.CheckAggregation(ts, aggregation) {
+			warnings = append(warnings, report.Mismatch{
+				Series:   i,
+				Location: fmt.Sprintf("timeseries[%d].%s", i, inconsistency.Location),
+				Fields:   []string{fieldName(inconsistency.Location)},
+				Reason:   fmt.Sprintf("%s: %s", ts.Description.CDID, inconsistency),
+			})
 		}
 	}
-	info(t, "Passed")
+	if len(warnings) == 0 {
+		return
+	}
+
+	if dataset != nil {
+		dataset.AddWarnings(warnings...)
+	}
+	for i, w := range warnings {
+		if i == maxChangedValues {
+			warn(t, fmt.Sprintf("... and %d more aggregation warnings", len(warnings)-i))
+			break
+		}
+		warn(t, fmt.Sprintf("%s: %s", w.Location, w.Reason))
+	}
+}
+
+// requireNoViolations emits and records each violation of a check that is independent of the golden, then fails
+// listing the first of them.
+func requireNoViolations(t *testing.T, dataset *report.Dataset, what string, violations []report.Mismatch) {
+	t.Helper()
+	assertion(t, len(violations) == 0, fmt.Sprintf("%d %s", len(violations), what))
+	if len(violations) == 0 {
+		return
+	}
+	for i := range violations {
+		emit(t, report.Event{Kind: report.EventMismatch, Mismatch: &violations[i]})
+	}
+	if dataset != nil {
+		dataset.AddMismatches(violations...)
+	}
+
+	lines := make([]string, 0, maxChangedValues+1)
+	for i, v := range violations {
+		if i == maxChangedValues {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(violations)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", v.Location, v.Reason))
+	}
+	t.Fatalf("\n%s:\n%s", colours.Bold(colours.Red(strings.ToUpper(what[:1])+what[1:])), colours.Red(strings.Join(lines, "\n")))
+}
+
+// fieldName returns the JSON names of the field at a location in a series, e.g. months.value for months[3].value.
+func fieldName(location string) string {
+	parts := strings.Split(location, ".")
+	for i, p := range parts {
+		parts[i] = strings.Split(p, "[")[0]
+	}
+	return strings.Join(parts, ".")
+}
+
+// mismatch is a report.Mismatch along with the values that differ, used to show a coloured diff in the test output.
+type mismatch struct {
+	report.Mismatch
+	actual   interface{}
+	expected interface{}
+}
+
+func newMismatch(index int, location, reason string, fields []string, actual, expected interface{}) mismatch {
+	return mismatch{
+		Mismatch: report.Mismatch{
+			Series:   index,
+			Location: location,
+			Fields:   fields,
+			Reason:   reason,
+			Diff:     jsonDiff(actual, expected, false),
+		},
+		actual:   actual,
+		expected: expected,
+	}
 }
 
-func compareTimeSeriesValue(t *testing.T, actual, expected TimeSeriesValue, reason string, fieldName string, tsIndex, fieldIndex int) {
-	if !assert.ObjectsAreEqual(actual, expected) {
-		location := fmt.Sprintf("timeseries[%d].%s[%d]", tsIndex, fieldName, fieldIndex)
-		jsonDiff := getJSONDiff(actual, expected)
+// compareTimeSeries requires each actual time series of the named dataset to match the expected time series at the
+// same index, comparing values with the tolerance rules. Every mismatch is recorded against the dataset report, if
+// there is one, before the test fails on the first. Differences accepted by a tolerance are logged and recorded but
+// do not fail the test.
+func compareTimeSeries(t *testing.T, name string, dataset *report.Dataset, actualTimeSeries, expectedTimeSeries []TimeSeries) {
+	mismatches, accepted, diff := findMismatches(name, actualTimeSeries, expectedTimeSeries)
+	if len(accepted) > 0 {
+		if dataset != nil {
+			dataset.AddAccepted(accepted...)
+		}
+		warn(t, fmt.Sprintf("%d differences accepted within tolerance, the first: %s", len(accepted), accepted[0]))
+	}
+
+	assertion(t, len(mismatches) == 0, fmt.Sprintf("%d mismatches with the expected time series", len(mismatches)))
+	if len(mismatches) == 0 {
+		return
+	}
+
+	for i := range mismatches {
+		emit(t, report.Event{Kind: report.EventMismatch, Mismatch: &mismatches[i].Mismatch})
+	}
+
+	if dataset != nil {
+		for _, m := range mismatches {
+			dataset.AddMismatches(m.Mismatch)
+			if m.Series >= 0 && m.Series < len(dataset.Series) {
+				dataset.Series[m.Series].Expected = &expectedTimeSeries[m.Series]
+				dataset.Series[m.Series].Actual = &actualTimeSeries[m.Series]
+			}
+		}
+	}
+
+	first := mismatches[0]
+	errReportFmt := "\n%s: %s\n%s: %s\n%s:\n%s\n%s:\n%s"
+	t.Fatalf(errReportFmt,
+		colours.Bold(colours.Red("Reason")), colours.Red(first.Reason),
+		colours.Bold(colours.Red("Location")), colours.Red(first.Location),
+		colours.Bold(colours.Red("JSON Diff:")), getJSONDiff(first.actual, first.expected),
+		colours.Bold(colours.Red("Changes")), diff.Format(maxChangedValues))
+}
 
-		errReportFmt := "\n%s: %s\n%s: %s\n%s:\n%s"
-		t.Fatalf(errReportFmt,
-			Bold(Red("Reason")), Red(reason),
-			Bold(Red("Location")), Red(location),
-			Bold(Red("JSON Diff:")), jsonDiff)
+// normaliseFields applies the field rules of the named dataset to each time series.
+func normaliseFields(name string, series []TimeSeries) []TimeSeries {
+	normalised := make([]TimeSeries, len(series))
+	for i, ts := range series {
+		normalised[i] = fieldRules.Normalise(name, ts)
 	}
+	return normalised
 }
 
+// findMismatches returns the mismatches between the actual and expected time series of the named dataset, the
+// differences in values accepted by the tolerance rules, and the diff of the series. The fields are compared once the
+// field rules are applied, any actual field not in the format of its rule being a mismatch. The years, quarters or
+// months holding the values of another block, or shifted by a few periods, are one mismatch each rather than one for
+// every value.
+func findMismatches(name string, actualTimeSeries, expectedTimeSeries []TimeSeries) ([]mismatch, []report.Mismatch, *timeseries.Diff) {
+	var mismatches []mismatch
+	var accepted []report.Mismatch
+	add := func(index int, location, reason string, fields []string, actual, expected interface{}) {
+		mismatches = append(mismatches, newMismatch(index, location, reason, fields, actual, expected))
+	}
+
+	normalisedActual, normalisedExpected := normaliseFields(name, actualTimeSeries), normaliseFields(name, expectedTimeSeries)
+	diff := timeseries.Compare(normalisedExpected, normalisedActual)
+	explained := explainedMismatches(diff, normalisedActual, normalisedExpected)
+
+	compareValues := func(index int, fieldName, lenErrFmt string, tolerance timeseries.Tolerance, actual, expected []TimeSeriesValue) {
+		if block, ok := explained[fmt.Sprintf("%d %s", index, fieldName)]; ok {
+			mismatches = append(mismatches, block...)
+			return
+		}
+
+		if len(actual) != len(expected) {
+			// Only diff the values beyond the end of the shorter list, the rest are compared individually below.
+			common := len(actual)
+			if len(expected) < common {
+				common = len(expected)
+			}
+			location := fmt.Sprintf("timeseries[%d].%s", index, fieldName)
+			key := fmt.Sprintf("%s[%d:]", fieldName, common)
+			add(index, location, fmt.Sprintf(lenErrFmt, index), []string{fieldName},
+				map[string]interface{}{key: actual[common:]}, map[string]interface{}{key: expected[common:]})
+		}
+
+		for i := 0; i < len(actual) && i < len(expected); i++ {
+			if assert.ObjectsAreEqual(actual[i], expected[i]) {
+				continue
+			}
+
+			location := fmt.Sprintf("timeseries[%d].%s[%d]", index, fieldName, i)
+			withExpectedValue := actual[i]
+			withExpectedValue.Value = expected[i].Value
+			if withExpectedValue == expected[i] && tolerance.Equal(expected[i].Value, actual[i].Value) {
+				accepted = append(accepted, report.Mismatch{
+					Series:   index,
+					Location: location,
+					Fields:   []string{fieldName + ".value"},
+					Reason:   fmt.Sprintf("value %q accepted as %q with %s", actual[i].Value, expected[i].Value, tolerance),
+				})
+				continue
+			}
+			add(index, location, "actual did not match expected", differingFields(fieldName, actual[i], expected[i]), actual[i], expected[i])
+		}
+	}
+
+	for index := 0; index < len(actualTimeSeries) && index < len(expectedTimeSeries); index++ {
+		for _, e := range fieldRules.CheckFormats(name, actualTimeSeries[index]) {
+			add(index, fmt.Sprintf("timeseries[%d].%s", index, e.Location), fmt.Sprintf("%q does not match the format %s", e.Value, e.Pattern),
+				[]string{e.Field}, map[string]interface{}{e.Field: e.Value}, map[string]interface{}{e.Field: e.Pattern})
+		}
+
+		actual := fieldRules.Normalise(name, actualTimeSeries[index])
+		expected := fieldRules.Normalise(name, expectedTimeSeries[index])
+
+		if !assert.ObjectsAreEqual(actual.Description, expected.Description) {
+			add(index, fmt.Sprintf("timeseries[%d].description", index), fmt.Sprintf(descErrFmt, index),
+				differingFields("description", actual.Description, expected.Description), actual.Description, expected.Description)
+		}
+		if actual.Type != expected.Type {
+			add(index, fmt.Sprintf("timeseries[%d].type", index), fmt.Sprintf(typeErrFmt, index), []string{"type"},
+				map[string]interface{}{"type": actual.Type}, map[string]interface{}{"type": expected.Type})
+		}
+
+		tolerance := toleranceRules.For(name, expected.Description.Unit, expected.Description.CDID)
+		compareValues(index, "years", yearsLenErrFmt, tolerance, actual.Years, expected.Years)
+		compareValues(index, "months", monthsLenErrFmt, tolerance, actual.Months, expected.Months)
+		compareValues(index, "quarters", quartersLenErrFmt, tolerance, actual.Quarters, expected.Quarters)
+	}
+	return mismatches, accepted, diff
+}
+
+// labelFields are the year, quarter and month labels of the values of the years, quarters and months.
+var labelFields = map[string][]string{
+	"years":    {"years.year"},
+	"quarters": {"quarters.year", "quarters.quarter"},
+	"months":   {"months.year", "months.quarter", "months.month"},
+}
+
+// explainedMismatches returns the mismatches of the years, quarters and months of the actual series that the
+// mappings and shifts of the diff explain, keyed by the index of the series and the name of the periods, e.g.
+// "0 quarters". A block mapped from elsewhere is one mapping error. A shifted block is one mismatch per shift, along
+// with any change the shift does not explain.
+func explainedMismatches(diff *timeseries.Diff, actualTimeSeries, expectedTimeSeries []TimeSeries) map[string][]mismatch {
+	explained := make(map[string][]mismatch)
+
+	for _, m := range diff.Mappings {
+		key := fmt.Sprintf("%d %s", m.ActualSeries, m.ActualPeriods)
+		explained[key] = append(explained[key], newMismatch(m.ActualSeries,
+			fmt.Sprintf("timeseries[%d].%s", m.ActualSeries, m.ActualPeriods), "mapping error: "+m.String(), []string{m.ActualPeriods},
+			map[string]interface{}{m.ActualPeriods: m.ExpectedCDID + " " + m.ExpectedPeriods},
+			map[string]interface{}{m.ActualPeriods: m.ActualCDID + " " + m.ActualPeriods}))
+	}
+
+	for _, sd := range diff.Series {
+		index := sd.ActualSeries
+		for _, pd := range sd.Periods {
+			key := fmt.Sprintf("%d %s", index, pd.Name)
+			if len(pd.Shifts) == 0 || len(explained[key]) > 0 {
+				continue
+			}
+			actual := periodValues(actualTimeSeries[index], pd.Name)
+			expected := periodValues(expectedTimeSeries[sd.ExpectedSeries], pd.Name)
+			location := fmt.Sprintf("timeseries[%d].%s", index, pd.Name)
+			// block adds a mismatch at the value of the date, or the whole block if the values do not have it.
+			block := func(values []TimeSeriesValue, date, reason string, fields []string, a, e interface{}) {
+				l := location
+				if j := indexOfDate(values, date); j >= 0 {
+					l = fmt.Sprintf("%s[%d]", location, j)
+				}
+				explained[key] = append(explained[key], newMismatch(index, l, reason, fields, a, e))
+			}
+
+			for _, s := range pd.Shifts {
+				fields := []string{pd.Name + ".value"}
+				if s.Labels {
+					fields = labelFields[pd.Name]
+				}
+				block(nil, "", fmt.Sprintf("shift error: %s %s %s", sd.CDID, pd.Name, s), fields,
+					map[string]interface{}{pd.Name: s.String()}, map[string]interface{}{pd.Name: "not shifted"})
+			}
+			for _, v := range pd.Values {
+				block(actual, v.Date, "value changed: "+v.String(), []string{pd.Name + ".value"},
+					map[string]interface{}{v.Date: v.Actual}, map[string]interface{}{v.Date: v.Expected})
+			}
+			// A removed period has no value in the actual series, so it is reported against the whole block, along with
+			// where it is in the expected series.
+			for _, p := range pd.Removed {
+				reason := fmt.Sprintf("period removed from the %s: %s", p.Position, p.Date)
+				if j := indexOfDate(expected, p.Date); j >= 0 {
+					reason += fmt.Sprintf(", expected at timeseries[%d].%s[%d]", sd.ExpectedSeries, pd.Name, j)
+				}
+				block(nil, p.Date, reason, []string{pd.Name}, map[string]interface{}{}, map[string]interface{}{p.Date: p.Value})
+			}
+			for _, p := range pd.Added {
+				block(actual, p.Date, fmt.Sprintf("period added to the %s: %s", p.Position, p.Date), []string{pd.Name},
+					map[string]interface{}{p.Date: p.Value}, map[string]interface{}{})
+			}
+			for _, f := range pd.Fields {
+				// The field is the date and name of the field, e.g. 1992 Q1 sourceDataset.
+				split := strings.LastIndex(f.Field, " ")
+				date, field := f.Field[:split], f.Field[split+1:]
+				block(actual, date, fmt.Sprintf("%s changed: %q → %q", f.Field, f.Expected, f.Actual), []string{pd.Name + "." + field},
+					map[string]interface{}{f.Field: f.Actual}, map[string]interface{}{f.Field: f.Expected})
+			}
+		}
+	}
+	return explained
+}
+
+// periodValues returns the years, quarters or months of a series.
+func periodValues(ts TimeSeries, name string) []TimeSeriesValue {
+	return map[string][]TimeSeriesValue{"years": ts.Years, "quarters": ts.Quarters, "months": ts.Months}[name]
+}
+
+// indexOfDate returns the index of the value with the date, or -1.
+func indexOfDate(values []TimeSeriesValue, date string) int {
+	for i, v := range values {
+		if v.Date == date {
+			return i
+		}
+	}
+	return -1
+}
+
+// differingFields returns the JSON names of the fields of two structs of the same type that differ, prefixed with
+// prefix, e.g. months.value.
+func differingFields(prefix string, a, b interface{}) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	var fields []string
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			name := strings.Split(va.Type().Field(i).Tag.Get("json"), ",")[0]
+			fields = append(fields, prefix+"."+name)
+		}
+	}
+	return fields
+}
+
+// getJSONDiff returns the JSON diff of a and b for the console, coloured only if the console output is.
 func getJSONDiff(a, b interface{}) string {
+	return jsonDiff(a, b, colourOutput)
+}
+
+func jsonDiff(a, b interface{}, coloring bool) string {
 	astr, _ := json.Marshal(a)
 	bstr, _ := json.Marshal(b)
 
@@ -176,7 +590,7 @@ func getJSONDiff(a, b interface{}) string {
 
 	config := formatter.AsciiFormatterConfig{
 		ShowArrayIndex: true,
-		Coloring:       true,
+		Coloring:       coloring,
 	}
 
 	formatter := formatter.NewAsciiFormatter(aJson, config)
@@ -193,31 +607,36 @@ func getCSDBRequestBody(filename string) (io.Reader, string, error) {
 		return nil, "", errors.Errorf("input file %s.csdb does not exist", filename)
 	}
 
-	body := &bytes.Buffer{}
-	bodyWriter := multipart.NewWriter(body)
-
-	fileWriter, err := bodyWriter.CreateFormFile("file", filename+".csdb")
+	f, err := os.Open(filepath)
 	if err != nil {
 		return nil, "", err
 	}
+	defer f.Close()
 
-	f, err := os.Open(filepath)
+	fi, err := f.Stat()
 	if err != nil {
 		return nil, "", err
 	}
-	defer f.Close()
 
-	copied, err := io.Copy(fileWriter, f)
+	return newCSDBRequestBody(filename+".csdb", f, fi.Size())
+}
+
+// newCSDBRequestBody creates a multipart request body uploading the content of r as a file named uploadName.
+func newCSDBRequestBody(uploadName string, r io.Reader, size int64) (io.Reader, string, error) {
+	body := &bytes.Buffer{}
+	bodyWriter := multipart.NewWriter(body)
+
+	fileWriter, err := bodyWriter.CreateFormFile("file", uploadName)
 	if err != nil {
 		return nil, "", err
 	}
 
-	fi, err := f.Stat()
+	copied, err := io.Copy(fileWriter, r)
 	if err != nil {
 		return nil, "", err
 	}
 
-	if fi.Size() != copied {
+	if size != copied {
 		return nil, "", errors.New("incorrect number of bytes copied")
 	}
 
@@ -231,9 +650,18 @@ func postCSDBFile(body io.Reader, contentType string) (*http.Response, error) {
 	// Requests to generate the csdb json for larger files (UKEA, RAGV) can take a loooooong time.
 	// I've arbitrarily set the timeout to 20 seconds but feel free to alter this as necessary.
 	timeout := time.Duration(20 * time.Second)
+	return postCSDBFileWithTimeout(body, contentType, timeout)
+}
+
+func postCSDBFileWithTimeout(body io.Reader, contentType string, timeout time.Duration) (*http.Response, error) {
+	return postFileTo("/Services/ConvertCSDB", body, contentType, timeout)
+}
+
+// postFileTo posts a multipart request body to the path of the brian host.
+func postFileTo(path string, body io.Reader, contentType string, timeout time.Duration) (*http.Response, error) {
 	httpClient := http.Client{Timeout: timeout}
 
-	url := fmt.Sprintf("%s/Services/ConvertCSDB", brianHost)
+	url := brianHost + path
 	resp, err := httpClient.Post(url, contentType, body)
 	if err != nil {
 		return nil, err
@@ -247,17 +675,47 @@ func readCSDBResponse(resp *http.Response) ([]TimeSeries, error) {
 	if err != nil {
 		return nil, err
 	}
+	return decodeCSDBResponse(data)
+}
 
+func decodeCSDBResponse(data []byte) ([]TimeSeries, error) {
 	var dataJson []TimeSeries
-	err = json.Unmarshal(data, &dataJson)
+	err := json.Unmarshal(data, &dataJson)
 	if err != nil {
 		return nil, err
 	}
 	return dataJson, err
 }
 
+// postCSDBBytes uploads data to brian as a file named uploadName.
+func postCSDBBytes(uploadName string, data []byte) (*http.Response, error) {
+	body, contentType, err := newCSDBRequestBody(uploadName, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return postCSDBFile(body, contentType)
+}
+
+// convertCSDB uploads data as a file named uploadName and returns the decoded response.
+func convertCSDB(uploadName string, data []byte) ([]TimeSeries, error) {
+	response, err := postCSDBBytes(uploadName, data)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("incorrect http response status code for POST CSDB request: %d", response.StatusCode)
+	}
+	return readCSDBResponse(response)
+}
+
+func expectedResultsPath(filename string) string {
+	return fmt.Sprintf("resources/outputs/%s-csdb.json", filename)
+}
+
 func getExpectedResults(filename string) ([]TimeSeries, error) {
-	b, err := ioutil.ReadFile(fmt.Sprintf("resources/outputs/%s-csdb.json", filename))
+	b, err := ioutil.ReadFile(expectedResultsPath(filename))
 	if err != nil {
 		return nil, err
 	}
@@ -277,34 +735,83 @@ func exists(path string) bool {
 	return true
 }
 
+// emit reports an event of the test to the reporters of the run.
+func emit(t *testing.T, e report.Event) {
+	t.Helper()
+	e.Time = time.Now()
+	e.Test = t.Name()
+	e.Log = t
+	reporter.Report(e)
+}
+
 func info(t *testing.T, message string) {
-	ToColour(t, Cyan, "info", message)
+	t.Helper()
+	emit(t, report.Event{Kind: report.EventLog, Keyword: "info", Message: message})
 }
 
-func ToColour(t *testing.T, colour func(arg interface{}) Value, prefix string, message string) {
-	t.Logf("%s: %s", Bold(colour(prefix)).String(), colour(message).String())
+func warn(t *testing.T, message string) {
+	t.Helper()
+	emit(t, report.Event{Kind: report.EventLog, Keyword: "warning", Message: message})
 }
 
+// Scenario starts a scenario, which ends along with the test.
 func Scenario(t *testing.T, message string) {
-	ToColour(t, Green, "Scenario", message)
+	t.Helper()
+	start := time.Now()
+	emit(t, report.Event{Kind: report.EventScenario, Message: message})
+	t.Cleanup(func() {
+		status := report.StatusPassed
+		switch {
+		case t.Failed():
+			status = report.StatusFailed
+		case t.Skipped():
+			status = report.StatusSkipped
+		}
+		emit(t, report.Event{Kind: report.EventScenarioEnd, Message: message, Status: status, Duration: time.Since(start)})
+	})
 }
 
 func Given(t *testing.T, message string) {
-	ToColour(t, Green, "Given", message)
+	t.Helper()
+	step(t, "Given", message)
 }
 
 func When(t *testing.T, message string) {
-	ToColour(t, Green, "When", message)
+	t.Helper()
+	step(t, "When", message)
 }
 
 func Then(t *testing.T, message string) {
-	ToColour(t, Green, "Then", message)
+	t.Helper()
+	step(t, "Then", message)
 }
 
 func And(t *testing.T, message string) {
-	ToColour(t, Green, "And", message)
+	t.Helper()
+	step(t, "And", message)
+}
+
+func step(t *testing.T, keyword, message string) {
+	t.Helper()
+	emit(t, report.Event{Kind: report.EventStep, Keyword: keyword, Message: message})
+}
+
+// assertion reports whether the check described by message passed.
+func assertion(t *testing.T, passed bool, message string) {
+	t.Helper()
+	status := report.StatusPassed
+	if !passed {
+		status = report.StatusFailed
+	}
+	emit(t, report.Event{Kind: report.EventAssertion, Message: message, Status: status})
+}
+
+// timing reports how long the operation described by message took.
+func timing(t *testing.T, message string, d time.Duration) {
+	t.Helper()
+	emit(t, report.Event{Kind: report.EventTiming, Message: message, Duration: d})
 }
 
 func Err(message string) string {
-	return Red(message).String()
+	return colours.Red(message).String()
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	determinism       = flag.Bool("determinism", false, "check repeated conversions of each file return byte-identical responses")
+	determinismRepeat = flag.Int("determinism.repeat", 3, "number of times each file is converted by the determinism check")
+)
+
+// determinismContext is the number of bytes either side of the first difference shown when responses differ.
+const determinismContext = 60
+
+func TestConvert_Determinism(t *testing.T) {
+	if !*determinism {
+		t.Skip("determinism check disabled, run with -determinism to enable")
+	}
+	require.True(t, *determinismRepeat > 1, Err("-determinism.repeat must be at least 2"))
+
+	var filenames []string
+	inputs := make(map[string][]byte)
+	for _, filename := range csdbFilenames {
+		b, err := ioutil.ReadFile(fmt.Sprintf("resources/inputs/%s.csdb", filename))
+		if err != nil {
+			info(t, fmt.Sprintf("skipping %s.csdb: %s", filename, err))
+			continue
+		}
+		filenames = append(filenames, filename)
+		inputs[filename] = b
+	}
+
+	Scenario(t, "Repeated conversions of the same file return byte-identical responses")
+
+	Given(t, fmt.Sprintf("the files %v", filenames))
+	When(t, fmt.Sprintf("each file is posted to /Services/ConvertCSDB %d times, after a different file each round", *determinismRepeat))
+
+	// Rotate the order each round so every file is converted straight after a different file.
+	responses := make(map[string][][]byte)
+	for round := 0; round < *determinismRepeat; round++ {
+		for i := range filenames {
+			filename := filenames[(i+round)%len(filenames)]
+			b, err := postCSDBRaw(filename, inputs[filename])
+			require.Nil(t, err, Err(fmt.Sprintf("error converting %s.csdb in round %d", filename, round+1)))
+			responses[filename] = append(responses[filename], b)
+		}
+	}
+
+	Then(t, "every response for a file is identical to the first")
+	for _, filename := range filenames {
+		t.Run(fmt.Sprintf("%s.csdb", filename), func(t *testing.T) {
+			first := responses[filename][0]
+			for round, b := range responses[filename][1:] {
+				assertSameBytes(t, first, b, round+2)
+			}
+			info(t, "Passed")
+		})
+	}
+}
+
+func postCSDBRaw(filename string, data []byte) ([]byte, error) {
+	response, err := postCSDBBytes(filename+".csdb", data)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("incorrect http response status code for POST CSDB request: %d", response.StatusCode)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+// assertSameBytes reports the first difference between two responses, noting whether the decoded time series are
+// still equal, in which case the difference is in ordering, number formatting or field order.
+func assertSameBytes(t *testing.T, expected, actual []byte, round int) {
+	if bytes.Equal(expected, actual) {
+		return
+	}
+
+	offset := 0
+	for offset < len(expected) && offset < len(actual) && expected[offset] == actual[offset] {
+		offset++
+	}
+
+	kind := "the decoded time series also differ"
+	var a, b []TimeSeries
+	if json.Unmarshal(expected, &a) == nil && json.Unmarshal(actual, &b) == nil && assert.ObjectsAreEqual(a, b) {
+		kind = "the decoded time series are equal so the difference is in ordering, formatting or field order"
+	}
+
+	t.Errorf("\n%s: %s\n%s: %s\n%s: %q\n%s: %q",
+		colours.Bold(colours.Red("Reason")), colours.Red(fmt.Sprintf("response %d differs from response 1 at byte %d, %s", round, offset, kind)),
+		colours.Bold(colours.Red("Lengths")), colours.Red(fmt.Sprintf("%d and %d bytes", len(expected), len(actual))),
+		colours.Bold(colours.Red("Response 1")), excerpt(expected, offset),
+		colours.Bold(colours.Red(fmt.Sprintf("Response %d", round))), excerpt(actual, offset))
+}
+
+func excerpt(b []byte, offset int) string {
+	start, end := offset-determinismContext, offset+determinismContext
+	if start < 0 {
+		start = 0
+	}
+	if end > len(b) {
+		end = len(b)
+	}
+	if start > end {
+		start = end
+	}
+	return string(b[start:end])
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/project-brian-api-test/csdb"
+	"github.com/ONSdigital/project-brian-api-test/gherkin"
+	"github.com/stretchr/testify/require"
+)
+
+var featuresGlob = flag.String("features", "resources/features/*.feature", "run the scenarios of the .feature files matching this glob")
+
+// featureWorld is the state the steps of a scenario share: the file to upload and brian's response to it.
+type featureWorld struct {
+	uploadName string
+	data       []byte
+	posted     bool
+	status     int
+	body       []byte
+}
+
+// response returns the decoded time series of a successful response.
+func (w *featureWorld) response(t *testing.T) []TimeSeries {
+	t.Helper()
+	require.True(t, w.posted, Err("no response, a file must be posted first"))
+	require.Equal(t, 200, w.status, Err(fmt.Sprintf("response status code was %d: %s", w.status, excerpt(w.body, 0))))
+
+	ts, err := decodeCSDBResponse(w.body)
+	require.Nil(t, err, Err("error reading csdb response json"))
+	return ts
+}
+
+type stepDefinition struct {
+	pattern *regexp.Regexp
+	run     func(t *testing.T, w *featureWorld, step gherkin.Step, args []string)
+}
+
+func defineStep(pattern string, run func(t *testing.T, w *featureWorld, step gherkin.Step, args []string)) stepDefinition {
+	return stepDefinition{pattern: regexp.MustCompile("^" + pattern + "$"), run: run}
+}
+
+// stepDefinitions are the steps a .feature file can use. Each pattern must match the whole text of the step, the
+// submatches are passed as args.
+var stepDefinitions = []stepDefinition{
+	defineStep(`the CSDB file (\S+\.csdb)`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		w.uploadName, w.data = args[0], readInput(t, args[0])
+	}),
+	defineStep(`the CSDB file (\S+\.csdb) uploaded as "([^"]*)"`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		w.uploadName, w.data = args[1], readInput(t, args[0])
+	}),
+	defineStep(`the CSDB file (\S+\.csdb) truncated to (\d+) bytes`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		data := readInput(t, args[0])
+		n, _ := strconv.Atoi(args[1])
+		if n < len(data) {
+			data = data[:n]
+		}
+		w.uploadName, w.data = args[0], data
+	}),
+	defineStep(`an empty CSDB file named (\S+)`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		w.uploadName, w.data = args[0], []byte{}
+	}),
+	defineStep(`a CSDB file named (\S+) containing:`, func(t *testing.T, w *featureWorld, step gherkin.Step, args []string) {
+		// CSDB files have Windows line endings.
+		content := strings.Replace(step.DocString, "\n", "\r\n", -1) + "\r\n"
+		w.uploadName, w.data = args[0], []byte(content)
+	}),
+
+	defineStep(`it is posted to (/\S*)`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		require.NotNil(t, w.data, Err("no file to post, a CSDB file must be given first"))
+		body, contentType, err := newCSDBRequestBody(w.uploadName, bytes.NewReader(w.data), int64(len(w.data)))
+		require.Nil(t, err, Err("error creating POST request"))
+
+		start := time.Now()
+		response, err := postFileTo(args[0], body, contentType, 20*time.Second)
+		require.Nil(t, err, Err("error sending POST request"))
+		defer response.Body.Close()
+
+		w.body, err = ioutil.ReadAll(response.Body)
+		require.Nil(t, err, Err("error reading response"))
+		timing(t, "POST "+args[0], time.Since(start))
+		w.posted, w.status = true, response.StatusCode
+	}),
+
+	defineStep(`the response status is (\d{3})`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		requireStatus(t, w, args[0], func(status string) bool { return status == args[0] })
+	}),
+	defineStep(`the response status is ([1-5])xx`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		requireStatus(t, w, args[0]+"xx", func(status string) bool { return strings.HasPrefix(status, args[0]) })
+	}),
+	defineStep(`the response status is not (\d{3})`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		requireStatus(t, w, "not "+args[0], func(status string) bool { return status != args[0] })
+	}),
+
+	defineStep(`the response matches golden (\S+)`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		actual := w.response(t)
+		expected, err := getExpectedResults(args[0])
+		require.Nil(t, err, Err("error reading expected csdb json file"))
+		require.Equal(t, len(expected), len(actual), Err("timeseries results length does not match expected"))
+		compareTimeSeries(t, args[0], nil, actual, expected)
+	}),
+	defineStep(`the response contains (\d+) time series`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		n, _ := strconv.Atoi(args[0])
+		require.Len(t, w.response(t), n, Err("incorrect number of time series"))
+	}),
+	defineStep(`the time series ([A-Z0-9]{4}) has (\d+) (years|quarters|months)`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		n, _ := strconv.Atoi(args[1])
+		for _, ts := range w.response(t) {
+			if ts.Description.CDID != args[0] {
+				continue
+			}
+			values := map[string][]TimeSeriesValue{"years": ts.Years, "quarters": ts.Quarters, "months": ts.Months}[args[2]]
+			require.Len(t, values, n, Err(fmt.Sprintf("incorrect number of %s for %s", args[2], args[0])))
+			return
+		}
+		t.Fatal(Err(fmt.Sprintf("no time series with CDID %s", args[0])))
+	}),
+	defineStep(`every time series satisfies the invariants`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		checkInvariants(t, nil, w.response(t))
+	}),
+	defineStep(`every time series agrees with the CSDB file`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		input, err := csdb.Parse(bytes.NewReader(w.data))
+		require.Nil(t, err, Err("error parsing the CSDB file"))
+		crossCheckInput(t, nil, input, w.response(t))
+	}),
+	defineStep(`the blocks of each CDID are merged by seasonal adjustment`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		input, err := csdb.Parse(bytes.NewReader(w.data))
+		require.Nil(t, err, Err("error parsing the CSDB file"))
+		checkMerging(t, nil, input, w.response(t))
+	}),
+	defineStep(`every value has the sourceDataset (\S+)`, func(t *testing.T, w *featureWorld, _ gherkin.Step, args []string) {
+		for i, ts := range w.response(t) {
+			for _, values := range [][]TimeSeriesValue{ts.Years, ts.Quarters, ts.Months} {
+				for _, v := range values {
+					require.Equal(t, args[0], v.SourceDataset, Err(fmt.Sprintf("timeseries[%d] %s has the wrong sourceDataset", i, v.Date)))
+				}
+			}
+		}
+	}),
+}
+
+func readInput(t *testing.T, filename string) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("resources/inputs", filename))
+	require.Nil(t, err, Err("error reading input file"))
+	return data
+}
+
+func requireStatus(t *testing.T, w *featureWorld, expected string, ok func(status string) bool) {
+	t.Helper()
+	require.True(t, w.posted, Err("no response, a file must be posted first"))
+	passed := ok(strconv.Itoa(w.status))
+	assertion(t, passed, fmt.Sprintf("response status code %d is %s", w.status, expected))
+	require.True(t, passed, Err(fmt.Sprintf("response status code was %d, expected %s: %s", w.status, expected, excerpt(w.body, 0))))
+}
+
+func TestFeatures(t *testing.T) {
+	paths, err := filepath.Glob(*featuresGlob)
+	require.Nil(t, err, Err("invalid -features glob"))
+
+	for _, path := range paths {
+		feature := readFeature(t, path)
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			for _, scenario := range feature.Scenarios {
+				scenario := scenario
+				t.Run(scenario.Name, func(t *testing.T) {
+					runScenario(t, path, feature, scenario)
+				})
+			}
+		})
+	}
+}
+
+func readFeature(t *testing.T, path string) *gherkin.Feature {
+	f, err := os.Open(path)
+	require.Nil(t, err, Err("error opening feature file"))
+	defer f.Close()
+
+	feature, err := gherkin.Parse(f, path)
+	require.Nil(t, err, Err("error parsing feature file"))
+	return feature
+}
+
+// runScenario runs the background steps of the feature and then the steps of the scenario, failing before running
+// any of them if a step is not defined.
+func runScenario(t *testing.T, path string, feature *gherkin.Feature, scenario gherkin.Scenario) {
+	Scenario(t, scenario.Name)
+
+	steps := append(append([]gherkin.Step(nil), feature.Background...), scenario.Steps...)
+	definitions := make([]stepDefinition, len(steps))
+	args := make([][]string, len(steps))
+	var undefined []string
+	for i, s := range steps {
+		var err error
+		definitions[i], args[i], err = findStep(s)
+		if err != nil {
+			undefined = append(undefined, fmt.Sprintf("%s:%d: %s", path, s.Line, err))
+		}
+	}
+	if len(undefined) > 0 {
+		t.Fatal(Err(strings.Join(undefined, "\n")))
+	}
+
+	w := &featureWorld{}
+	for i, s := range steps {
+		step(t, s.Keyword, s.Text)
+		definitions[i].run(t, w, s, args[i])
+	}
+}
+
+func findStep(s gherkin.Step) (stepDefinition, []string, error) {
+	var found []stepDefinition
+	var args []string
+	for _, d := range stepDefinitions {
+		if m := d.pattern.FindStringSubmatch(s.Text); m != nil {
+			found = append(found, d)
+			args = m[1:]
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return stepDefinition{}, nil, fmt.Errorf("undefined step %q", s.String())
+	case 1:
+		return found[0], args, nil
+	}
+	return stepDefinition{}, nil, fmt.Errorf("ambiguous step %q matches %d definitions", s.String(), len(found))
+}
@@ -0,0 +1,317 @@
+// Package gherkin parses the subset of the Gherkin language used to describe brian scenarios in .feature files:
+//
+//	# comments
+//	@tags
+//	Feature: name
+//	  free text description
+//
+//	  Background:
+//	    Given steps run before every scenario
+//
+//	  Scenario: name
+//	    Given a step
+//	    When another step
+//	      """
+//	      a doc string passed to the step
+//	      """
+//	    Then a step with a table
+//	      | a | b |
+//
+//	  Scenario Outline: name with <placeholder>
+//	    Given a step using <placeholder>
+//
+//	    Examples:
+//	      | placeholder |
+//	      | value       |
+//
+// Scenario outlines are expanded into a scenario per example row.
+package gherkin
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Feature is a parsed .feature file.
+type Feature struct {
+	Name        string
+	Description string
+	Tags        []string
+	Background  []Step
+	Scenarios   []Scenario
+}
+
+// Scenario is a named sequence of steps. Outlines are expanded, so every scenario is ready to run.
+type Scenario struct {
+	Name  string
+	Line  int
+	Tags  []string
+	Steps []Step
+}
+
+// Step is a Given, When, Then, And, But or * step.
+type Step struct {
+	Keyword   string
+	Text      string
+	Line      int
+	DocString string
+	Table     [][]string
+}
+
+// String returns the step as written, e.g. "Given the CSDB file ott.csdb".
+func (s Step) String() string {
+	return s.Keyword + " " + s.Text
+}
+
+var stepKeywords = []string{"Given", "When", "Then", "And", "But", "*"}
+
+type parser struct {
+	name    string
+	line    int
+	feature *Feature
+	tags    []string
+
+	// steps is where the next step is added: the background or the current scenario.
+	steps      *[]Step
+	scenario   *Scenario
+	outline    bool
+	inExamples bool
+	// examples are the tables of the outline's Examples blocks, each with its own header row.
+	examples [][][]string
+}
+
+// Parse reads a feature from r. Errors are prefixed with name and the line number.
+func Parse(r io.Reader, name string) (*Feature, error) {
+	p := &parser{name: name}
+	scanner := bufio.NewScanner(r)
+
+	var docString *strings.Builder
+	var docIndent string
+	for scanner.Scan() {
+		p.line++
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		line := strings.TrimSpace(raw)
+
+		if docString != nil {
+			if line == `"""` {
+				step := p.lastStep()
+				step.DocString = strings.TrimSuffix(docString.String(), "\n")
+				docString = nil
+				continue
+			}
+			docString.WriteString(strings.TrimPrefix(raw, docIndent))
+			docString.WriteString("\n")
+			continue
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var err error
+		switch {
+		case line == `"""`:
+			if p.lastStep() == nil {
+				return nil, p.errorf("doc string must follow a step")
+			}
+			docString = &strings.Builder{}
+			docIndent = raw[:strings.Index(raw, `"""`)]
+		case strings.HasPrefix(line, "|"):
+			err = p.tableRow(line)
+		case strings.HasPrefix(line, "@"):
+			p.tags = append(p.tags, strings.Fields(line)...)
+		default:
+			err = p.keywordLine(line)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error reading %s", name)
+	}
+	if docString != nil {
+		return nil, p.errorf("unterminated doc string")
+	}
+	if p.feature == nil {
+		return nil, p.errorf("no Feature")
+	}
+	if err := p.endScenario(); err != nil {
+		return nil, err
+	}
+	return p.feature, nil
+}
+
+func (p *parser) keywordLine(line string) error {
+	keyword, text := splitKeyword(line)
+	switch keyword {
+	case "Feature":
+		if p.feature != nil {
+			return p.errorf("a file can only contain one Feature")
+		}
+		p.feature = &Feature{Name: text, Tags: p.takeTags()}
+		return nil
+	case "Background":
+		if p.feature == nil || p.scenario != nil {
+			return p.errorf("Background must come after the Feature and before any Scenario")
+		}
+		p.steps = &p.feature.Background
+		return nil
+	case "Scenario", "Example", "Scenario Outline", "Scenario Template":
+		if p.feature == nil {
+			return p.errorf("%s before Feature", keyword)
+		}
+		if err := p.endScenario(); err != nil {
+			return err
+		}
+		tags := append(append([]string(nil), p.feature.Tags...), p.takeTags()...)
+		p.scenario = &Scenario{Name: text, Line: p.line, Tags: tags}
+		p.steps = &p.scenario.Steps
+		p.outline = keyword == "Scenario Outline" || keyword == "Scenario Template"
+		return nil
+	case "Examples", "Scenarios":
+		if !p.outline {
+			return p.errorf("%s must belong to a Scenario Outline", keyword)
+		}
+		p.inExamples = true
+		p.examples = append(p.examples, nil)
+		return nil
+	}
+
+	for _, k := range stepKeywords {
+		if strings.HasPrefix(line, k+" ") {
+			if p.steps == nil {
+				return p.errorf("step %q must belong to a Background or Scenario", line)
+			}
+			if p.inExamples {
+				return p.errorf("step %q after Examples", line)
+			}
+			*p.steps = append(*p.steps, Step{Keyword: k, Text: strings.TrimSpace(line[len(k):]), Line: p.line})
+			return nil
+		}
+	}
+
+	if p.feature != nil && p.steps == nil {
+		if p.feature.Description != "" {
+			p.feature.Description += "\n"
+		}
+		p.feature.Description += line
+		return nil
+	}
+	return p.errorf("unexpected %q", line)
+}
+
+// splitKeyword splits "Scenario: name" into "Scenario" and "name". Lines without a colon have no keyword.
+func splitKeyword(line string) (string, string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", line
+	}
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+}
+
+func (p *parser) tableRow(line string) error {
+	if !strings.HasSuffix(line, "|") {
+		return p.errorf("table row must end with |")
+	}
+	var cells []string
+	for _, cell := range strings.Split(line[1:len(line)-1], "|") {
+		cells = append(cells, strings.TrimSpace(cell))
+	}
+
+	if p.inExamples {
+		table := &p.examples[len(p.examples)-1]
+		if len(*table) > 0 && len(cells) != len((*table)[0]) {
+			return p.errorf("examples row has %d cells, the header has %d", len(cells), len((*table)[0]))
+		}
+		*table = append(*table, cells)
+		return nil
+	}
+
+	step := p.lastStep()
+	if step == nil {
+		return p.errorf("table must follow a step")
+	}
+	step.Table = append(step.Table, cells)
+	return nil
+}
+
+func (p *parser) lastStep() *Step {
+	if p.steps == nil || len(*p.steps) == 0 {
+		return nil
+	}
+	return &(*p.steps)[len(*p.steps)-1]
+}
+
+func (p *parser) takeTags() []string {
+	tags := p.tags
+	p.tags = nil
+	return tags
+}
+
+// endScenario adds the current scenario to the feature, expanding it if it is an outline.
+func (p *parser) endScenario() error {
+	s := p.scenario
+	if s == nil {
+		return nil
+	}
+	p.scenario, p.steps = nil, nil
+
+	if !p.outline {
+		p.feature.Scenarios = append(p.feature.Scenarios, *s)
+		return nil
+	}
+
+	examples := p.examples
+	p.outline, p.inExamples, p.examples = false, false, nil
+	var rows, headers [][]string
+	for _, table := range examples {
+		if len(table) == 0 {
+			continue
+		}
+		for _, row := range table[1:] {
+			rows, headers = append(rows, row), append(headers, table[0])
+		}
+	}
+	if len(rows) == 0 {
+		return errors.Errorf("%s:%d: Scenario Outline %q has no Examples", p.name, s.Line, s.Name)
+	}
+
+	for i, row := range rows {
+		header := headers[i]
+		replacer := make([]string, 0, len(header)*2)
+		for j, name := range header {
+			replacer = append(replacer, "<"+name+">", row[j])
+		}
+		r := strings.NewReplacer(replacer...)
+
+		expanded := Scenario{Name: r.Replace(s.Name), Line: s.Line, Tags: s.Tags}
+		if expanded.Name == s.Name {
+			expanded.Name = fmt.Sprintf("%s (example %d)", s.Name, i+1)
+		}
+		for _, step := range s.Steps {
+			step.Text = r.Replace(step.Text)
+			step.DocString = r.Replace(step.DocString)
+			var table [][]string
+			for _, tr := range step.Table {
+				var cells []string
+				for _, cell := range tr {
+					cells = append(cells, r.Replace(cell))
+				}
+				table = append(table, cells)
+			}
+			step.Table = table
+			expanded.Steps = append(expanded.Steps, step)
+		}
+		p.feature.Scenarios = append(p.feature.Scenarios, expanded)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return errors.Errorf("%s:%d: %s", p.name, p.line, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,116 @@
+package gherkin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const feature = `# Conversion of CSDB files
+@convert
+Feature: Convert CSDB files
+  Brian converts CSDB files to time series JSON.
+
+  Background:
+    Given brian is running
+
+  @golden
+  Scenario: ott converts
+    Given the CSDB file ott.csdb
+    When it is posted to /Services/ConvertCSDB
+    Then the response status is 200
+    And the response matches golden ott
+
+  Scenario: a file with a doc string
+    Given a CSDB file named bad.csdb containing:
+      """
+       0 header
+         indented
+      """
+    Then the response has the time series:
+      | cdid | years |
+      | GMAA | 36    |
+
+  Scenario Outline: <name> converts
+    Given the CSDB file <name>.csdb
+    Then the response matches golden <name>
+
+    Examples:
+      | name |
+      | bb   |
+      | berd |
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(feature), "convert.feature")
+	require.Nil(t, err)
+
+	assert.Equal(t, "Convert CSDB files", f.Name)
+	assert.Equal(t, "Brian converts CSDB files to time series JSON.", f.Description)
+	assert.Equal(t, []string{"@convert"}, f.Tags)
+	assert.Equal(t, []Step{{Keyword: "Given", Text: "brian is running", Line: 7}}, f.Background)
+	require.Len(t, f.Scenarios, 4)
+
+	ott := f.Scenarios[0]
+	assert.Equal(t, "ott converts", ott.Name)
+	assert.Equal(t, 10, ott.Line)
+	assert.Equal(t, []string{"@convert", "@golden"}, ott.Tags)
+	require.Len(t, ott.Steps, 4)
+	assert.Equal(t, "When it is posted to /Services/ConvertCSDB", ott.Steps[1].String())
+	assert.Equal(t, 14, ott.Steps[3].Line)
+
+	doc := f.Scenarios[1]
+	assert.Equal(t, []string{"@convert"}, doc.Tags)
+	assert.Equal(t, " 0 header\n   indented", doc.Steps[0].DocString)
+	assert.Equal(t, [][]string{{"cdid", "years"}, {"GMAA", "36"}}, doc.Steps[1].Table)
+
+	assert.Equal(t, "bb converts", f.Scenarios[2].Name)
+	assert.Equal(t, "Given the CSDB file bb.csdb", f.Scenarios[2].Steps[0].String())
+	assert.Equal(t, "Then the response matches golden berd", f.Scenarios[3].Steps[1].String())
+}
+
+func TestParse_ExamplesBlocks(t *testing.T) {
+	f, err := Parse(strings.NewReader(`Feature: x
+  Scenario Outline: <name> converts
+    Then the response matches golden <name>
+
+    Examples: small
+      | name |
+      | ott  |
+
+    Examples: large
+      | name | timeout |
+      | ragv | 60s     |
+      | sppi | 30s     |
+`), "x.feature")
+	require.Nil(t, err)
+
+	var names []string
+	for _, s := range f.Scenarios {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"ott converts", "ragv converts", "sppi converts"}, names)
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, c := range []struct {
+		input string
+		err   string
+	}{
+		{"Scenario: x\n", "x.feature:1: Scenario before Feature"},
+		{"Feature: x\nGiven a step\n", `x.feature:2: step "Given a step" must belong to a Background or Scenario`},
+		{"Feature: x\nScenario: y\nGiven a\n\"\"\"\nunterminated\n", "x.feature:5: unterminated doc string"},
+		{"Feature: x\nScenario: y\nGiven a\n| a | b\n", "x.feature:4: table row must end with |"},
+		{"Feature: x\nScenario Outline: y\nGiven <a>\n", `x.feature:2: Scenario Outline "y" has no Examples`},
+		{"Feature: x\nScenario: y\nExamples:\n", "x.feature:3: Examples must belong to a Scenario Outline"},
+		{"Feature: x\nScenario: y\nsomething else\n", `x.feature:3: unexpected "something else"`},
+		{"# nothing\n", "x.feature:1: no Feature"},
+	} {
+		_, err := Parse(strings.NewReader(c.input), "x.feature")
+		if assert.NotNil(t, err, c.input) {
+			assert.Equal(t, c.err, err.Error())
+		}
+	}
+}
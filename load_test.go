@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/project-brian-api-test/perf"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	load             = flag.Bool("load", false, "drive /Services/ConvertCSDB with a mix of files and report latency and throughput")
+	loadMix          = flag.String("load.mix", "ott=1,berd=1,sppi=1", "comma separated files and their relative weights, e.g. ott=3,bb=1")
+	loadConcurrency  = flag.Int("load.concurrency", 4, "number of requests in flight at once")
+	loadRate         = flag.Float64("load.rate", 0, "maximum requests per second, 0 for no limit")
+	loadDuration     = flag.Duration("load.duration", 30*time.Second, "how long to generate load for")
+	loadSoakWindow   = flag.Duration("load.soak", 0, "soak mode: summarise each window of this length and check latency does not drift")
+	loadMaxDrift     = flag.Float64("load.maxdrift", 25, "soak mode: maximum percentage increase in p50 latency from the first to the last window")
+	loadMaxErrorRate = flag.Float64("load.maxerrorrate", 0, "maximum fraction of requests allowed to fail")
+)
+
+type loadFixture struct {
+	filename    string
+	body        []byte
+	contentType string
+}
+
+func TestConvert_Load(t *testing.T) {
+	if !*load {
+		t.Skip("load test disabled, run with -load to enable")
+	}
+	require.True(t, *loadConcurrency > 0, Err("-load.concurrency must be at least 1"))
+
+	sequence, err := loadSequence(*loadMix)
+	require.Nil(t, err, Err("invalid -load.mix"))
+
+	Scenario(t, "brian keeps up with a sustained mix of conversions")
+
+	Given(t, fmt.Sprintf("the mix %s", *loadMix))
+	When(t, fmt.Sprintf("requests are sent for %s with %d in flight at up to %s requests per second",
+		*loadDuration, *loadConcurrency, rateString(*loadRate)))
+	samples, elapsed := runLoad(sequence, *loadConcurrency, *loadRate, *loadDuration)
+
+	Then(t, "the latency, error rate and throughput are reported")
+	summary := perf.Summarise(samples, elapsed)
+	info(t, fmt.Sprintf("all: %s", summary))
+
+	byDataset := perf.ByDataset(samples)
+	datasets := make([]string, 0, len(byDataset))
+	for dataset := range byDataset {
+		datasets = append(datasets, dataset)
+	}
+	sort.Strings(datasets)
+	for _, dataset := range datasets {
+		info(t, fmt.Sprintf("%s: %s", dataset, perf.Summarise(byDataset[dataset], elapsed)))
+	}
+
+	for _, s := range samples {
+		if s.Err != nil {
+			info(t, fmt.Sprintf("first error: %s: %s", s.Dataset, s.Err))
+			break
+		}
+	}
+
+	And(t, fmt.Sprintf("no more than %.2f%% of requests fail", *loadMaxErrorRate*100))
+	require.True(t, summary.ErrorRate <= *loadMaxErrorRate, Err(fmt.Sprintf("error rate %.2f%% exceeded the maximum", summary.ErrorRate*100)))
+
+	if *loadSoakWindow > 0 {
+		And(t, fmt.Sprintf("the p50 latency does not drift by more than %.0f%% between %s windows", *loadMaxDrift, *loadSoakWindow))
+		windows := perf.Windows(samples, *loadSoakWindow)
+		for i, w := range windows {
+			info(t, fmt.Sprintf("window %d: %s", i+1, w))
+		}
+
+		drift := perf.Drift(windows)
+		require.True(t, drift <= *loadMaxDrift, Err(fmt.Sprintf("p50 latency drifted by %+.1f%% from the first to the last window, brian may be leaking resources", drift)))
+	}
+	info(t, "Passed")
+}
+
+// loadSequence expands a mix such as "ott=3,bb=1" into the order the files are sent in.
+func loadSequence(mix string) ([]loadFixture, error) {
+	var sequence []loadFixture
+	for _, entry := range strings.Split(mix, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		filename, weight := entry, 1
+		if i := strings.Index(entry, "="); i >= 0 {
+			var err error
+			filename = entry[:i]
+			if weight, err = strconv.Atoi(entry[i+1:]); err != nil || weight < 1 {
+				return nil, errors.Errorf("invalid weight in %q", entry)
+			}
+		}
+
+		body, contentType, err := getCSDBRequestBody(filename)
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < weight; i++ {
+			sequence = append(sequence, loadFixture{filename: filename, body: b, contentType: contentType})
+		}
+	}
+
+	if len(sequence) == 0 {
+		return nil, errors.New("no files in mix")
+	}
+	return sequence, nil
+}
+
+func runLoad(sequence []loadFixture, concurrency int, rate float64, duration time.Duration) ([]perf.Sample, time.Duration) {
+	start := time.Now()
+	deadline := start.Add(duration)
+
+	var throttle <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	var next int64 = -1
+	var mutex sync.Mutex
+	var samples []perf.Sample
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if throttle != nil {
+					<-throttle
+				}
+				if time.Now().After(deadline) {
+					return
+				}
+
+				fixture := sequence[int(atomic.AddInt64(&next, 1))%len(sequence)]
+				sample := loadRequest(fixture, start)
+
+				mutex.Lock()
+				samples = append(samples, sample)
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Offset < samples[j].Offset })
+	return samples, time.Since(start)
+}
+
+func loadRequest(fixture loadFixture, start time.Time) perf.Sample {
+	requestStart := time.Now()
+	sample := perf.Sample{
+		Dataset:      fixture.filename,
+		Offset:       requestStart.Sub(start),
+		RequestBytes: int64(len(fixture.body)),
+	}
+
+	response, err := postCSDBFile(bytes.NewReader(fixture.body), fixture.contentType)
+	if err != nil {
+		sample.Err = err
+		sample.Latency = time.Since(requestStart)
+		return sample
+	}
+	defer response.Body.Close()
+
+	sample.ResponseBytes, err = io.Copy(ioutil.Discard, response.Body)
+	sample.Latency = time.Since(requestStart)
+	if err != nil {
+		sample.Err = err
+	} else if response.StatusCode != http.StatusOK {
+		sample.Err = errors.Errorf("incorrect http response status code: %d", response.StatusCode)
+	}
+	return sample
+}
+
+func rateString(rate float64) string {
+	if rate <= 0 {
+		return "unlimited"
+	}
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
@@ -10,6 +10,10 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"sort"
+	"time"
+
+	"github.com/ONSdigital/project-brian-api-test/perf"
 )
 
 func main() {
@@ -23,61 +27,88 @@ func main() {
 	fmt.Println("finished project-brian responses")
 }
 
+// baselineSamples is how many times each dataset is converted, the baseline recording the median latency so a
+// single slow request, such as the first while brian warms up, does not skew it.
+const baselineSamples = 3
+
 func storeBaselineResponses(filename string) error {
+	var respData []byte
+	var latencies []time.Duration
+	for len(latencies) < baselineSamples {
+		data, latency, err := postDataset(filename)
+		if err != nil {
+			return err
+		}
+		respData = data
+		latencies = append(latencies, latency)
+	}
+
+	var temp []interface{}
+
+	err := json.Unmarshal(respData, &temp)
+	if err != nil {
+		return err
+	}
+
+	pretty, err := json.MarshalIndent(temp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(fmt.Sprintf("resources/outputs/%s-csdb.json", filename), pretty, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	latency := perf.Percentile(latencies, 50)
+	return perf.WriteBaseline(perf.BaselinePath("resources/baselines", filename), perf.NewBaseline(latency, int64(len(respData))))
+}
+
+// postDataset posts resources/inputs/<filename>.csdb to brian, returning the response and how long it took.
+func postDataset(filename string) ([]byte, time.Duration, error) {
 	body := &bytes.Buffer{}
 	bodyWriter := multipart.NewWriter(body)
 
 	fileWriter, err := bodyWriter.CreateFormFile("file", filename+".csdb")
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	f, err := os.Open(fmt.Sprintf("resources/inputs/%s.csdb", filename))
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 	defer f.Close()
 
 	copied, err := io.Copy(fileWriter, f)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	fi, err := f.Stat()
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	if fi.Size() != copied {
-		return errors.New("number bytes copied to request did not match the file size")
+		return nil, 0, errors.New("number bytes copied to request did not match the file size")
 	}
 
 	contentType := bodyWriter.FormDataContentType()
 	bodyWriter.Close()
 
+	start := time.Now()
 	resp, err := http.Post("http://localhost:8083/Services/ConvertCSDB", contentType, body)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	defer resp.Body.Close()
 
 	respData, err := ioutil.ReadAll(resp.Body)
-	var temp []interface{}
-
-	err = json.Unmarshal(respData, &temp)
 	if err != nil {
-		return err
-	}
-
-	pretty, err := json.MarshalIndent(temp, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	err = ioutil.WriteFile(fmt.Sprintf("resources/outputs/%s-csdb.json", filename), pretty, os.ModePerm)
-	if err != nil {
-		return err
+		return nil, 0, err
 	}
-	return nil
+	return respData, time.Since(start), nil
 }
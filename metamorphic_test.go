@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/project-brian-api-test/csdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The metamorphic tests derive new inputs from the fixtures and check how the responses relate to each other rather
+// than comparing them to the captured goldens. The smaller fixtures are used to keep the number of requests down.
+var metamorphicFilenames = []string{
+	"ott",
+	"berd",
+	"sppi",
+}
+
+func TestMetamorphic_SeriesOrder(t *testing.T) {
+	for _, filename := range metamorphicFilenames {
+		t.Run(fmt.Sprintf("%s.csdb", filename), func(t *testing.T) {
+			Scenario(t, "Reversing the order of the series blocks does not change the time series returned")
+
+			Given(t, fmt.Sprintf("a valid %s.csdb file and a copy with its series blocks reversed", filename))
+			file := readCSDBFixture(t, filename)
+
+			reversed := make([]*csdb.Series, 0, len(file.Series))
+			for i := len(file.Series) - 1; i >= 0; i-- {
+				reversed = append(reversed, file.Series[i])
+			}
+
+			When(t, "both files are posted to /Services/ConvertCSDB")
+			original, err := convertCSDB(filename+".csdb", file.Bytes())
+			require.Nil(t, err, Err("error converting original file"))
+
+			actual, err := convertCSDB(filename+".csdb", file.WithSeries(reversed).Bytes())
+			require.Nil(t, err, Err("error converting reversed file"))
+
+			Then(t, "the same time series are returned for each CDID")
+			requireSameSeriesByCDID(t, indexByCDID(original), indexByCDID(actual))
+			info(t, "Passed")
+		})
+	}
+}
+
+func TestMetamorphic_FileSplitting(t *testing.T) {
+	for _, filename := range metamorphicFilenames {
+		t.Run(fmt.Sprintf("%s.csdb", filename), func(t *testing.T) {
+			Scenario(t, "Converting a file in two halves returns the same time series as converting the whole file")
+
+			Given(t, fmt.Sprintf("a valid %s.csdb file split in two between CDIDs", filename))
+			file := readCSDBFixture(t, filename)
+
+			groups := file.GroupByCDID()
+			require.True(t, len(groups) > 1, Err("file must contain more than one CDID to be split"))
+
+			var first, second []*csdb.Series
+			for i, group := range groups {
+				if i < len(groups)/2 {
+					first = append(first, group...)
+				} else {
+					second = append(second, group...)
+				}
+			}
+
+			When(t, "the whole file and each half are posted to /Services/ConvertCSDB")
+			whole, err := convertCSDB(filename+".csdb", file.Bytes())
+			require.Nil(t, err, Err("error converting whole file"))
+
+			firstHalf, err := convertCSDB(filename+".csdb", file.WithSeries(first).Bytes())
+			require.Nil(t, err, Err("error converting first half"))
+
+			secondHalf, err := convertCSDB(filename+".csdb", file.WithSeries(second).Bytes())
+			require.Nil(t, err, Err("error converting second half"))
+
+			Then(t, "the union of the halves matches the whole file")
+			requireSameSeriesByCDID(t, indexByCDID(whole), indexByCDID(append(firstHalf, secondHalf...)))
+			info(t, "Passed")
+		})
+	}
+}
+
+func TestMetamorphic_SingleValueChange(t *testing.T) {
+	for _, filename := range metamorphicFilenames {
+		t.Run(fmt.Sprintf("%s.csdb", filename), func(t *testing.T) {
+			Scenario(t, "Changing a single 97 value changes exactly one value in the response")
+
+			Given(t, fmt.Sprintf("a valid %s.csdb file and a copy with one value changed", filename))
+			file := readCSDBFixture(t, filename)
+
+			seriesIndex, valueIndex := len(file.Series)/2, -1
+			modified := file.Series[seriesIndex].Clone()
+			for i, v := range modified.Values {
+				if v != "" {
+					valueIndex = i
+					break
+				}
+			}
+			require.True(t, valueIndex >= 0, Err("series has no values to change"))
+
+			oldValue := modified.Values[valueIndex]
+			newValue := incrementValue(t, oldValue)
+			require.Nil(t, modified.SetValue(valueIndex, newValue))
+
+			series := append([]*csdb.Series(nil), file.Series...)
+			series[seriesIndex] = modified
+
+			When(t, "both files are posted to /Services/ConvertCSDB")
+			original, err := convertCSDB(filename+".csdb", file.Bytes())
+			require.Nil(t, err, Err("error converting original file"))
+
+			actual, err := convertCSDB(filename+".csdb", file.WithSeries(series).Bytes())
+			require.Nil(t, err, Err("error converting modified file"))
+
+			Then(t, fmt.Sprintf("only %s value %d has changed from %s to %s", modified.ID, valueIndex, oldValue, newValue))
+			expected, got := indexByCDID(original), indexByCDID(actual)
+			require.Equal(t, sortedKeys(expected), sortedKeys(got), Err("CDIDs returned did not match"))
+
+			var changes []string
+			for cdid, expectedSeries := range expected {
+				actualSeries := got[cdid]
+				require.Len(t, actualSeries, len(expectedSeries), Err(fmt.Sprintf("number of time series for %s did not match", cdid)))
+				for i := range expectedSeries {
+					changes = append(changes, changedValues(t, cdid, expectedSeries[i], actualSeries[i])...)
+				}
+			}
+
+			require.Len(t, changes, 1, Err(fmt.Sprintf("expected exactly one changed value, got %v", changes)))
+			assert.Contains(t, changes[0], modified.CDID(), Err("the change was reported against the wrong CDID"))
+			assert.Contains(t, changes[0], fmt.Sprintf("%q -> %q", oldValue, newValue))
+			info(t, "Passed")
+		})
+	}
+}
+
+func readCSDBFixture(t *testing.T, filename string) *csdb.File {
+	b, err := ioutil.ReadFile(fmt.Sprintf("resources/inputs/%s.csdb", filename))
+	require.Nil(t, err, Err("error reading input file"))
+
+	file, err := csdb.Parse(bytes.NewReader(b))
+	require.Nil(t, err, Err("error parsing input file"))
+	return file
+}
+
+// indexByCDID groups time series by CDID. Series sharing a CDID are sorted so the groups can be compared regardless
+// of the order brian returned them in.
+func indexByCDID(series []TimeSeries) map[string][]TimeSeries {
+	index := make(map[string][]TimeSeries)
+	for _, ts := range series {
+		index[ts.Description.CDID] = append(index[ts.Description.CDID], ts)
+	}
+
+	for _, group := range index {
+		sort.Slice(group, func(i, j int) bool {
+			a, _ := json.Marshal(group[i])
+			b, _ := json.Marshal(group[j])
+			return bytes.Compare(a, b) < 0
+		})
+	}
+	return index
+}
+
+func sortedKeys(index map[string][]TimeSeries) []string {
+	keys := make([]string, 0, len(index))
+	for k := range index {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func requireSameSeriesByCDID(t *testing.T, expected, actual map[string][]TimeSeries) {
+	require.Equal(t, sortedKeys(expected), sortedKeys(actual), Err("CDIDs returned did not match"))
+
+	for _, cdid := range sortedKeys(expected) {
+		if !assert.ObjectsAreEqual(expected[cdid], actual[cdid]) {
+			t.Fatalf("\n%s: %s\n%s:\n%s",
+				colours.Bold(colours.Red("Reason")), colours.Red(fmt.Sprintf("time series for CDID %s did not match", cdid)),
+				colours.Bold(colours.Red("JSON Diff:")), getJSONDiff(map[string]interface{}{"series": actual[cdid]}, map[string]interface{}{"series": expected[cdid]}))
+		}
+	}
+}
+
+// changedValues lists every difference between two time series, requiring that everything other than the
+// value fields is unchanged.
+func changedValues(t *testing.T, cdid string, expected, actual TimeSeries) []string {
+	require.Equal(t, expected.Description, actual.Description, Err(fmt.Sprintf("description of %s changed", cdid)))
+	require.Equal(t, expected.Type, actual.Type, Err(fmt.Sprintf("type of %s changed", cdid)))
+
+	var changes []string
+	compare := func(fieldName string, expected, actual []TimeSeriesValue) {
+		require.Len(t, actual, len(expected), Err(fmt.Sprintf("%s.%s length changed", cdid, fieldName)))
+		for i := range expected {
+			e, a := expected[i], actual[i]
+			if e.Value != a.Value {
+				changes = append(changes, fmt.Sprintf("%s.%s[%d] (%s): %q -> %q", cdid, fieldName, i, e.Date, e.Value, a.Value))
+				e.Value = a.Value
+			}
+			require.Equal(t, e, a, Err(fmt.Sprintf("%s.%s[%d] changed", cdid, fieldName, i)))
+		}
+	}
+
+	compare("years", expected.Years, actual.Years)
+	compare("quarters", expected.Quarters, actual.Quarters)
+	compare("months", expected.Months, actual.Months)
+	return changes
+}
+
+// incrementValue adds one to a CSDB value keeping its number of decimal places.
+func incrementValue(t *testing.T, value string) string {
+	f, err := strconv.ParseFloat(value, 64)
+	require.Nil(t, err, Err(fmt.Sprintf("value %q is not a number", value)))
+
+	decimals := 0
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		decimals = len(value) - i - 1
+	}
+	return strconv.FormatFloat(f+1, 'f', decimals, 64)
+}
@@ -0,0 +1,98 @@
+package perf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Baseline is the recorded median latency and response size of converting a dataset.
+type Baseline struct {
+	LatencyMillis int64  `json:"latencyMillis"`
+	ResponseBytes int64  `json:"responseBytes"`
+	Recorded      string `json:"recorded"`
+}
+
+// NewBaseline creates a baseline from a measured conversion.
+func NewBaseline(latency time.Duration, responseBytes int64) Baseline {
+	return Baseline{
+		LatencyMillis: int64(latency / time.Millisecond),
+		ResponseBytes: responseBytes,
+		Recorded:      time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// BaselinePath returns the path of the baseline of a dataset in the directory, e.g. resources/baselines/ott.perf.json.
+func BaselinePath(dir, dataset string) string {
+	return filepath.Join(dir, dataset+".perf.json")
+}
+
+// ReadBaseline reads a baseline file, returning nil if it does not exist.
+func ReadBaseline(path string) (*Baseline, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(b, &baseline); err != nil {
+		return nil, err
+	}
+	return &baseline, nil
+}
+
+// WriteBaseline writes a baseline file, creating its directory if need be.
+func WriteBaseline(path string, baseline Baseline) error {
+	b, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Regression is a metric that has grown compared to its baseline.
+type Regression struct {
+	Metric   string
+	Baseline int64
+	Actual   int64
+	Change   float64
+	Fail     bool
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s increased by %.1f%% from %d to %d", r.Metric, r.Change, r.Baseline, r.Actual)
+}
+
+// Compare returns the metrics that have grown by more than warnPercent compared to the baseline. Regressions larger
+// than failPercent are marked as failures, none if failPercent is 0.
+func (b Baseline) Compare(latency time.Duration, responseBytes int64, warnPercent, failPercent float64) []Regression {
+	var regressions []Regression
+	check := func(metric string, baseline, actual int64) {
+		if baseline <= 0 {
+			return
+		}
+		change := float64(actual-baseline) / float64(baseline) * 100
+		if change > warnPercent {
+			regressions = append(regressions, Regression{
+				Metric:   metric,
+				Baseline: baseline,
+				Actual:   actual,
+				Change:   change,
+				Fail:     failPercent > 0 && change > failPercent,
+			})
+		}
+	}
+
+	check("latency (ms)", b.LatencyMillis, int64(latency/time.Millisecond))
+	check("response size (bytes)", b.ResponseBytes, responseBytes)
+	return regressions
+}
@@ -0,0 +1,50 @@
+package perf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselinePath(t *testing.T) {
+	assert.Equal(t, "resources/baselines/ott.perf.json", BaselinePath("resources/baselines", "ott"))
+}
+
+func TestBaseline_ReadWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "baselines", "ott.perf.json")
+	missing, err := ReadBaseline(path)
+	require.Nil(t, err)
+	assert.Nil(t, missing)
+
+	require.Nil(t, WriteBaseline(path, NewBaseline(1500*time.Millisecond, 2048)))
+	baseline, err := ReadBaseline(path)
+	require.Nil(t, err)
+	assert.Equal(t, int64(1500), baseline.LatencyMillis)
+	assert.Equal(t, int64(2048), baseline.ResponseBytes)
+}
+
+func TestBaseline_Compare(t *testing.T) {
+	baseline := Baseline{LatencyMillis: 1000, ResponseBytes: 1000}
+
+	assert.Empty(t, baseline.Compare(1100*time.Millisecond, 900, 20, 50))
+
+	regressions := baseline.Compare(1300*time.Millisecond, 1600, 20, 50)
+	require.Len(t, regressions, 2)
+	assert.Equal(t, "latency (ms)", regressions[0].Metric)
+	assert.InDelta(t, 30, regressions[0].Change, 0.001)
+	assert.False(t, regressions[0].Fail)
+	assert.True(t, regressions[1].Fail)
+
+	regressions = baseline.Compare(1300*time.Millisecond, 1600, 20, 0)
+	require.Len(t, regressions, 2)
+	assert.False(t, regressions[1].Fail, "failing should be disabled")
+}
@@ -0,0 +1,121 @@
+package perf
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+)
+
+// Point is a single measurement on a chart.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Chart is a line chart of measurements against a common x axis.
+type Chart struct {
+	Title  string
+	XLabel string
+	YLabel string
+	Points []Point
+}
+
+const (
+	chartWidth  = 640
+	chartHeight = 320
+	chartMargin = 60
+)
+
+// WriteSVG writes the charts stacked vertically as a single SVG image. Each chart joins its points with a line and
+// draws a dashed line through the origin and the first point, showing where the points would be if they grew
+// linearly with x.
+func WriteSVG(w io.Writer, charts []Chart) error {
+	ew := &errWriter{w: w}
+	ew.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n",
+		chartWidth, chartHeight*len(charts))
+	ew.printf(`<rect width="100%%" height="100%%" fill="white"/>` + "\n")
+	for i, c := range charts {
+		ew.printf(`<g transform="translate(0,%d)">`+"\n", i*chartHeight)
+		writeChart(ew, c)
+		ew.printf("</g>\n")
+	}
+	ew.printf("</svg>\n")
+	return ew.err
+}
+
+func writeChart(ew *errWriter, c Chart) {
+	maxX, maxY := 0.0, 0.0
+	for _, p := range c.Points {
+		if p.X > maxX {
+			maxX = p.X
+		}
+		if p.Y > maxY {
+			maxY = p.Y
+		}
+	}
+	if maxX == 0 {
+		maxX = 1
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+	maxX *= 1.1
+	maxY *= 1.1
+
+	left, top := float64(chartMargin), float64(chartMargin)/2
+	plotW, plotH := float64(chartWidth-chartMargin*3/2), float64(chartHeight-chartMargin*3/2)
+	x := func(v float64) float64 { return left + v/maxX*plotW }
+	y := func(v float64) float64 { return top + plotH - v/maxY*plotH }
+
+	ew.printf(`<text x="%d" y="%g" font-size="14" font-weight="bold">%s</text>`+"\n", chartMargin, top-8, html.EscapeString(c.Title))
+	ew.printf(`<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="black"/>`+"\n", left, top+plotH, left+plotW, top+plotH)
+	ew.printf(`<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="black"/>`+"\n", left, top, left, top+plotH)
+
+	for i := 0; i <= 4; i++ {
+		xv, yv := maxX*float64(i)/4, maxY*float64(i)/4
+		ew.printf(`<text x="%g" y="%g" text-anchor="middle">%s</text>`+"\n", x(xv), top+plotH+16, label(xv))
+		ew.printf(`<text x="%g" y="%g" text-anchor="end">%s</text>`+"\n", left-4, y(yv)+4, label(yv))
+		ew.printf(`<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="#ddd"/>`+"\n", left, y(yv), left+plotW, y(yv))
+	}
+	ew.printf(`<text x="%g" y="%g" text-anchor="middle">%s</text>`+"\n", left+plotW/2, top+plotH+36, html.EscapeString(c.XLabel))
+	ew.printf(`<text transform="translate(14,%g) rotate(-90)" text-anchor="middle">%s</text>`+"\n", top+plotH/2, html.EscapeString(c.YLabel))
+
+	if len(c.Points) == 0 {
+		return
+	}
+
+	if first := c.Points[0]; first.X > 0 {
+		endX, endY := maxX, first.Y/first.X*maxX
+		if endY > maxY {
+			endX, endY = maxY*first.X/first.Y, maxY
+		}
+		ew.printf(`<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="#999" stroke-dasharray="4 4"/>`+"\n", x(0), y(0), x(endX), y(endY))
+	}
+
+	ew.printf(`<polyline fill="none" stroke="steelblue" stroke-width="2" points="`)
+	for _, p := range c.Points {
+		ew.printf("%g,%g ", x(p.X), y(p.Y))
+	}
+	ew.printf(`"/>` + "\n")
+	for _, p := range c.Points {
+		ew.printf(`<circle cx="%g" cy="%g" r="3" fill="steelblue"><title>%s, %s</title></circle>`+"\n", x(p.X), y(p.Y), label(p.X), label(p.Y))
+	}
+}
+
+func label(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// errWriter keeps the first error from a sequence of writes.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,32 @@
+package perf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSVG(t *testing.T) {
+	charts := []Chart{
+		{Title: "Response time <ms>", XLabel: "input", YLabel: "ms", Points: []Point{{1, 10}, {2, 40}, {4, 160}}},
+		{Title: "Empty"},
+	}
+
+	var b bytes.Buffer
+	require.Nil(t, WriteSVG(&b, charts))
+
+	svg := b.String()
+	decoder := xml.NewDecoder(&b)
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err, "chart should be well formed XML")
+	}
+	assert.Contains(t, svg, "Response time &lt;ms&gt;")
+}
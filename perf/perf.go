@@ -0,0 +1,140 @@
+// Package perf summarises the latency and throughput of requests made to project-brian.
+package perf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Sample is the outcome of a single request.
+type Sample struct {
+	Dataset string
+	// Offset is when the request started, relative to the start of the run.
+	Offset        time.Duration
+	Latency       time.Duration
+	RequestBytes  int64
+	ResponseBytes int64
+	Err           error
+}
+
+// Summary describes a set of samples.
+type Summary struct {
+	Requests   int
+	Errors     int
+	ErrorRate  float64
+	Mean       time.Duration
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	Elapsed    time.Duration
+	UploadMBps float64
+	ResultMBps float64
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("requests=%d errors=%d (%.2f%%) p50=%s p95=%s p99=%s max=%s upload=%.2fMB/s response=%.2fMB/s",
+		s.Requests, s.Errors, s.ErrorRate*100, round(s.P50), round(s.P95), round(s.P99), round(s.Max), s.UploadMBps, s.ResultMBps)
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
+
+// Summarise calculates latency percentiles from the successful samples, the error rate and the throughput over
+// elapsed.
+func Summarise(samples []Sample, elapsed time.Duration) Summary {
+	s := Summary{Requests: len(samples), Elapsed: elapsed}
+
+	var latencies []time.Duration
+	var total time.Duration
+	var uploaded, downloaded int64
+	for _, sample := range samples {
+		if sample.Err != nil {
+			s.Errors++
+			continue
+		}
+		latencies = append(latencies, sample.Latency)
+		total += sample.Latency
+		uploaded += sample.RequestBytes
+		downloaded += sample.ResponseBytes
+	}
+
+	if s.Requests > 0 {
+		s.ErrorRate = float64(s.Errors) / float64(s.Requests)
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		s.Mean = total / time.Duration(len(latencies))
+		s.P50 = Percentile(latencies, 50)
+		s.P95 = Percentile(latencies, 95)
+		s.P99 = Percentile(latencies, 99)
+		s.Max = latencies[len(latencies)-1]
+	}
+	if elapsed > 0 {
+		s.UploadMBps = float64(uploaded) / 1e6 / elapsed.Seconds()
+		s.ResultMBps = float64(downloaded) / 1e6 / elapsed.Seconds()
+	}
+	return s
+}
+
+// Percentile returns the nearest-rank percentile p (0-100] of latencies, which must be sorted.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// ByDataset groups samples by dataset.
+func ByDataset(samples []Sample) map[string][]Sample {
+	groups := make(map[string][]Sample)
+	for _, s := range samples {
+		groups[s.Dataset] = append(groups[s.Dataset], s)
+	}
+	return groups
+}
+
+// Windows splits samples into consecutive windows by their start offset and summarises each one. Empty windows are
+// omitted.
+func Windows(samples []Sample, window time.Duration) []Summary {
+	if window <= 0 {
+		return nil
+	}
+
+	var buckets [][]Sample
+	for _, s := range samples {
+		i := int(s.Offset / window)
+		for len(buckets) <= i {
+			buckets = append(buckets, nil)
+		}
+		buckets[i] = append(buckets[i], s)
+	}
+
+	var summaries []Summary
+	for _, bucket := range buckets {
+		if len(bucket) > 0 {
+			summaries = append(summaries, Summarise(bucket, window))
+		}
+	}
+	return summaries
+}
+
+// Drift returns the percentage change in p50 latency between the first and last windows. A steady increase over a
+// soak run is a sign of a resource leak.
+func Drift(windows []Summary) float64 {
+	if len(windows) < 2 || windows[0].P50 == 0 {
+		return 0
+	}
+	first, last := windows[0].P50, windows[len(windows)-1].P50
+	return float64(last-first) / float64(first) * 100
+}
@@ -0,0 +1,60 @@
+package perf
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, ms(i))
+	}
+
+	assert.Equal(t, ms(50), Percentile(latencies, 50))
+	assert.Equal(t, ms(95), Percentile(latencies, 95))
+	assert.Equal(t, ms(99), Percentile(latencies, 99))
+	assert.Equal(t, ms(100), Percentile(latencies, 100))
+	assert.Equal(t, ms(1), Percentile(latencies, 0))
+	assert.Equal(t, time.Duration(0), Percentile(nil, 50))
+}
+
+func TestSummarise(t *testing.T) {
+	samples := []Sample{
+		{Latency: ms(10), RequestBytes: 1e6, ResponseBytes: 2e6},
+		{Latency: ms(30), RequestBytes: 1e6, ResponseBytes: 2e6},
+		{Latency: ms(20), RequestBytes: 1e6, ResponseBytes: 2e6},
+		{Latency: ms(5000), Err: errors.New("timeout")},
+	}
+
+	s := Summarise(samples, 2*time.Second)
+	assert.Equal(t, 4, s.Requests)
+	assert.Equal(t, 1, s.Errors)
+	assert.Equal(t, 0.25, s.ErrorRate)
+	assert.Equal(t, ms(20), s.Mean)
+	assert.Equal(t, ms(20), s.P50)
+	assert.Equal(t, ms(30), s.P99)
+	assert.Equal(t, 1.5, s.UploadMBps)
+	assert.Equal(t, 3.0, s.ResultMBps)
+}
+
+func TestWindowsAndDrift(t *testing.T) {
+	samples := []Sample{
+		{Offset: ms(0), Latency: ms(100)},
+		{Offset: ms(500), Latency: ms(100)},
+		{Offset: ms(2100), Latency: ms(150)},
+		{Offset: ms(2500), Latency: ms(150)},
+	}
+
+	windows := Windows(samples, time.Second)
+	assert.Len(t, windows, 2, "empty windows should be omitted")
+	assert.Equal(t, 50.0, Drift(windows))
+	assert.Equal(t, 0.0, Drift(windows[:1]))
+}
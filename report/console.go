@@ -0,0 +1,76 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/logrusorgru/aurora"
+)
+
+// Console writes scenarios, steps and log messages for a person to read, e.g.
+//
+//	Scenario: The correct JSON is generated for a given ott.csdb file
+//	Given: a valid ott.csdb file
+//
+// Assertions and mismatches are left to the test failure output, and timings to the other reporters.
+type Console struct {
+	mutex   sync.Mutex
+	w       io.Writer
+	colours aurora.Aurora
+}
+
+// NewConsole returns a reporter writing to the logger of each event, or to w for events without one. Output is
+// coloured if colour is true.
+func NewConsole(w io.Writer, colour bool) *Console {
+	return &Console{w: w, colours: aurora.NewAurora(colour)}
+}
+
+// ColourEnabled returns whether to colour output written to f: only when f is a terminal and neither NO_COLOR is set
+// nor TERM is dumb.
+func ColourEnabled(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Report writes the event if it is one the console shows.
+func (c *Console) Report(e Event) {
+	var colour func(arg interface{}) aurora.Value
+	prefix := e.Keyword
+	switch e.Kind {
+	case EventScenario:
+		colour, prefix = c.colours.Green, "Scenario"
+	case EventStep:
+		colour = c.colours.Green
+	case EventLog:
+		colour = c.colours.Cyan
+		if e.Keyword == "warning" {
+			colour = c.colours.Brown
+		}
+	default:
+		return
+	}
+
+	line := fmt.Sprintf("%s: %s", c.colours.Bold(colour(prefix)), colour(e.Message))
+	if e.Log != nil {
+		e.Log.Helper()
+		e.Log.Logf("%s", line)
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	fmt.Fprintln(c.w, line)
+}
+
+// Close does nothing, the console is written as events are reported.
+func (c *Console) Close() error {
+	return nil
+}
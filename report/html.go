@@ -0,0 +1,264 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
+)
+
+// periodRow is a period of a series shown side by side in the HTML report.
+type periodRow struct {
+	Date     string
+	Expected string
+	Actual   string
+	Changed  bool
+	Note     string
+}
+
+// periodTable is the comparison of one of the years, quarters or months of a series.
+type periodTable struct {
+	Name      string
+	Rows      []periodRow
+	Changed   int
+	Sparkline template.HTML
+}
+
+type htmlSeries struct {
+	Index      int
+	Series     Series
+	Mismatches []Mismatch
+	Tables     []periodTable
+}
+
+type htmlDataset struct {
+	*Dataset
+	Other   []Mismatch
+	Failing []htmlSeries
+}
+
+// NewHTML returns a reporter writing the datasets of the run as an HTML report to w when it is closed.
+func NewHTML(w io.Writer, run *Run) *RunReporter {
+	return NewRunReporter(w, run, WriteHTML)
+}
+
+// WriteHTML writes a self-contained HTML report of the run. Every failed dataset lists its mismatching series with a
+// side by side table of the expected and actual values of each period and a sparkline overlaying the two.
+func WriteHTML(w io.Writer, run *Run) error {
+	var datasets []htmlDataset
+	for _, d := range run.Datasets {
+		hd := htmlDataset{Dataset: d, Other: d.SeriesMismatches(-1)}
+		for i, s := range d.Series {
+			mismatches := d.SeriesMismatches(i)
+			if len(mismatches) == 0 {
+				continue
+			}
+
+			hs := htmlSeries{Index: i, Series: s, Mismatches: mismatches}
+			if s.Expected != nil && s.Actual != nil {
+				hs.Tables = periodTables(*s.Expected, *s.Actual)
+			}
+			hd.Failing = append(hd.Failing, hs)
+		}
+		datasets = append(datasets, hd)
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Run      *Run
+		Datasets []htmlDataset
+	}{run, datasets})
+}
+
+func periodTables(expected, actual timeseries.TimeSeries) []periodTable {
+	var tables []periodTable
+	for _, p := range []struct {
+		name             string
+		expected, actual []timeseries.TimeSeriesValue
+	}{
+		{"years", expected.Years, actual.Years},
+		{"quarters", expected.Quarters, actual.Quarters},
+		{"months", expected.Months, actual.Months},
+	} {
+		table := periodTable{Name: p.name, Rows: periodRows(p.expected, p.actual)}
+		for _, r := range table.Rows {
+			if r.Changed {
+				table.Changed++
+			}
+		}
+		if table.Changed > 0 {
+			table.Sparkline = sparkline(p.expected, p.actual)
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// periodRows matches the expected and actual values by date, in the expected order followed by any dates only in
+// the actual values.
+func periodRows(expected, actual []timeseries.TimeSeriesValue) []periodRow {
+	actualByDate := make(map[string]timeseries.TimeSeriesValue)
+	for _, v := range actual {
+		actualByDate[v.Date] = v
+	}
+
+	var rows []periodRow
+	seen := make(map[string]bool)
+	for _, e := range expected {
+		seen[e.Date] = true
+		a, ok := actualByDate[e.Date]
+		if !ok {
+			rows = append(rows, periodRow{Date: e.Date, Expected: e.Value, Changed: true, Note: "missing from actual"})
+			continue
+		}
+
+		row := periodRow{Date: e.Date, Expected: e.Value, Actual: a.Value, Changed: e != a}
+		if e.Value == a.Value && e != a {
+			row.Note = otherDifferences(e, a)
+		}
+		rows = append(rows, row)
+	}
+
+	for _, a := range actual {
+		if !seen[a.Date] {
+			rows = append(rows, periodRow{Date: a.Date, Actual: a.Value, Changed: true, Note: "not expected"})
+		}
+	}
+	return rows
+}
+
+func otherDifferences(e, a timeseries.TimeSeriesValue) string {
+	var notes []string
+	add := func(name, expected, actual string) {
+		if expected != actual {
+			notes = append(notes, fmt.Sprintf("%s: %q → %q", name, expected, actual))
+		}
+	}
+	add("year", e.Year, a.Year)
+	add("quarter", e.Quarter, a.Quarter)
+	add("month", e.Month, a.Month)
+	add("sourceDataset", e.SourceDataset, a.SourceDataset)
+	return strings.Join(notes, ", ")
+}
+
+const (
+	sparklineWidth  = 320
+	sparklineHeight = 48
+)
+
+// sparkline draws the expected and actual values as two lines on the same scale.
+func sparkline(expected, actual []timeseries.TimeSeriesValue) template.HTML {
+	n := len(expected)
+	if len(actual) > n {
+		n = len(actual)
+	}
+
+	min, max := 0.0, 0.0
+	first := true
+	for _, values := range [][]timeseries.TimeSeriesValue{expected, actual} {
+		for _, v := range values {
+			f, err := strconv.ParseFloat(v.Value, 64)
+			if err != nil {
+				continue
+			}
+			if first || f < min {
+				min = f
+			}
+			if first || f > max {
+				max = f
+			}
+			first = false
+		}
+	}
+	if max == min {
+		max = min + 1
+	}
+
+	line := func(values []timeseries.TimeSeriesValue, colour string) string {
+		var points []string
+		for i, v := range values {
+			f, err := strconv.ParseFloat(v.Value, 64)
+			if err != nil {
+				continue
+			}
+			x := 0.0
+			if n > 1 {
+				x = float64(i) / float64(n-1) * sparklineWidth
+			}
+			y := sparklineHeight - (f-min)/(max-min)*sparklineHeight
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		return fmt.Sprintf(`<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, colour, strings.Join(points, " "))
+	}
+
+	return template.HTML(fmt.Sprintf(`<svg class="sparkline" width="%d" height="%d" viewBox="0 -2 %d %d">%s%s</svg>`,
+		sparklineWidth, sparklineHeight, sparklineWidth, sparklineHeight+4,
+		line(expected, "#888"), line(actual, "#d33")))
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>project-brian ConvertCSDB report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 0.5em 0 1.5em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: right; }
+th { background: #f4f4f4; }
+td.text, th.text { text-align: left; }
+tr.changed td { background: #fde2e2; }
+.passed { color: #2a7d2a; }
+.failed { color: #c62828; }
+.skipped { color: #888; }
+.legend span { display: inline-block; width: 1em; height: 3px; vertical-align: middle; margin: 0 4px 0 12px; }
+pre { background: #f8f8f8; padding: 0.5em; overflow-x: auto; }
+details { margin-bottom: 1em; }
+</style>
+</head>
+<body>
+<h1>project-brian ConvertCSDB report</h1>
+<p>Host <code>{{.Run.Host}}</code>, started {{.Run.Started.Format "2006-01-02 15:04:05"}}, took {{.Run.Duration}}.</p>
+
+<table>
+<tr><th class="text">Dataset</th><th class="text">Status</th><th>Duration</th><th>Series</th><th>Values</th><th>Mismatches</th><th>Accepted</th><th>Warnings</th></tr>
+{{range .Datasets}}<tr>
+<td class="text">{{if or .Mismatches .Accepted .Warnings}}<a href="#{{.Name}}">{{.Name}}.csdb</a>{{else}}{{.Name}}.csdb{{end}}</td>
+<td class="text {{.Status}}">{{.Status}}</td>
+<td>{{.Duration}}</td><td>{{len .Series}}</td><td>{{.ValueCount}}</td><td>{{len .Mismatches}}</td><td>{{len .Accepted}}</td><td>{{len .Warnings}}</td>
+</tr>
+{{end}}</table>
+
+{{range .Datasets}}{{if or (eq .Status "failed") .Accepted .Warnings}}
+<h2 id="{{.Name}}">{{.Name}}.csdb</h2>
+{{if .Failure}}<p class="failed">{{.Failure}}</p>{{end}}
+{{if .Accepted}}<details><summary>{{len .Accepted}} differences accepted within tolerance</summary><ul>
+{{range .Accepted}}<li>{{.Location}}: {{.Reason}}</li>
+{{end}}</ul></details>
+{{end}}
+{{if .Warnings}}<details><summary>{{len .Warnings}} data-quality warnings</summary><ul>
+{{range .Warnings}}<li>{{.Location}}: {{.Reason}}</li>
+{{end}}</ul></details>
+{{end}}
+{{range .Other}}<details open><summary>{{.Location}}: {{.Reason}}</summary><pre>{{.Diff}}</pre></details>
+{{end}}
+{{range .Failing}}
+<h3>timeseries[{{.Index}}] {{.Series.CDID}}: {{.Series.Title}}{{if .Series.Unit}} ({{.Series.Unit}}){{end}}</h3>
+<p>{{len .Mismatches}} mismatches.</p>
+{{if not .Tables}}{{range .Mismatches}}<details open><summary>{{.Location}}: {{.Reason}}</summary><pre>{{.Diff}}</pre></details>
+{{end}}{{end}}
+{{range .Tables}}
+<h4>{{.Name}}: {{.Changed}} of {{len .Rows}} periods differ</h4>
+<div>{{.Sparkline}}<div class="legend"><span style="background:#888"></span>expected<span style="background:#d33"></span>actual</div></div>
+<table>
+<tr><th class="text">Period</th><th>Expected</th><th>Actual</th><th class="text">Notes</th></tr>
+{{range .Rows}}<tr{{if .Changed}} class="changed"{{end}}><td class="text">{{.Date}}</td><td>{{.Expected}}</td><td>{{.Actual}}</td><td class="text">{{.Note}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+{{end}}{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,260 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       float64         `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// NewJUnit returns a reporter writing the datasets of the run as JUnit XML to w when it is closed, as WriteJUnit does.
+func NewJUnit(w io.Writer, run *Run, perSeries bool) *RunReporter {
+	return NewRunReporter(w, run, func(w io.Writer, run *Run) error {
+		return WriteJUnit(w, run, perSeries)
+	})
+}
+
+// WriteJUnit writes the run as JUnit XML with a test case per dataset. If perSeries is true each dataset is written as
+// its own test suite with a test case per expected time series, plus a test case for the dataset as a whole.
+func WriteJUnit(w io.Writer, run *Run, perSeries bool) error {
+	suites := junitTestSuites{Name: "project-brian", Time: run.Duration.Seconds()}
+
+	if perSeries {
+		for _, d := range run.Datasets {
+			suites.Suites = append(suites.Suites, seriesSuite(run, d))
+		}
+	} else {
+		suite := junitTestSuite{
+			Name:       "ConvertCSDB",
+			Time:       run.Duration.Seconds(),
+			Timestamp:  run.Started.UTC().Format("2006-01-02T15:04:05"),
+			Properties: []junitProperty{{Name: "brian.host", Value: run.Host}},
+		}
+		for _, d := range run.Datasets {
+			suite.add(datasetCase(d))
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	for _, s := range suites.Suites {
+		suites.Tests += s.Tests
+		suites.Failures += s.Failures
+		suites.Skipped += s.Skipped
+	}
+
+	return writeJUnitXML(w, suites)
+}
+
+func writeJUnitXML(w io.Writer, suites junitTestSuites) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (s *junitTestSuite) add(c junitTestCase) {
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	if c.Failure != nil {
+		s.Failures++
+	}
+	if c.Skipped != nil {
+		s.Skipped++
+	}
+}
+
+func datasetCase(d *Dataset) junitTestCase {
+	c := junitTestCase{Name: d.Name + ".csdb", ClassName: "ConvertCSDB", Time: d.Duration.Seconds()}
+	switch d.Status {
+	case StatusFailed:
+		c.Failure = &junitFailure{
+			Message: d.Failure,
+			Type:    "mismatch",
+			Body:    mismatchText(d.Mismatches),
+		}
+	case StatusSkipped:
+		c.Skipped = &struct{}{}
+	}
+	return c
+}
+
+func seriesSuite(run *Run, d *Dataset) junitTestSuite {
+	suite := junitTestSuite{
+		Name:       d.Name + ".csdb",
+		Time:       d.Duration.Seconds(),
+		Timestamp:  run.Started.UTC().Format("2006-01-02T15:04:05"),
+		Properties: []junitProperty{{Name: "brian.host", Value: run.Host}},
+	}
+
+	whole := datasetCase(d)
+	whole.Name = "response"
+	whole.ClassName = d.Name
+	if whole.Failure != nil {
+		whole.Failure.Body = mismatchText(d.SeriesMismatches(-1))
+	}
+	suite.add(whole)
+
+	for i, s := range d.Series {
+		c := junitTestCase{Name: fmt.Sprintf("timeseries[%d] %s %s", i, s.CDID, s.Title), ClassName: d.Name}
+		if mismatches := d.SeriesMismatches(i); len(mismatches) > 0 {
+			c.Failure = &junitFailure{Message: mismatches[0].String(), Type: "mismatch", Body: mismatchText(mismatches)}
+		} else if d.Status == StatusSkipped {
+			c.Skipped = &struct{}{}
+		}
+		suite.add(c)
+	}
+	return suite
+}
+
+func mismatchText(mismatches []Mismatch) string {
+	var b strings.Builder
+	for _, m := range mismatches {
+		fmt.Fprintf(&b, "Reason: %s\nLocation: %s\n", m.Reason, m.Location)
+		if m.Diff != "" {
+			fmt.Fprintf(&b, "JSON Diff:\n%s\n", m.Diff)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// JUnitScenarios is a reporter writing a test case for every scenario, named after the scenario and classed by the test that
+// ran it. Each case fails with the first failed assertion or mismatch and its body lists the steps of the scenario.
+type JUnitScenarios struct {
+	mutex   sync.Mutex
+	w       io.Writer
+	started time.Time
+	cases   []*junitTestCase
+	// running is the case of the current scenario of each test, and the steps reported so far.
+	running map[string]*junitScenario
+}
+
+type junitScenario struct {
+	c     *junitTestCase
+	steps strings.Builder
+}
+
+// NewJUnitScenarios returns a reporter writing JUnit XML to w when it is closed.
+func NewJUnitScenarios(w io.Writer) *JUnitScenarios {
+	return &JUnitScenarios{w: w, started: time.Now(), running: make(map[string]*junitScenario)}
+}
+
+// Report records the event against the current scenario of its test.
+func (j *JUnitScenarios) Report(e Event) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if e.Kind == EventScenario {
+		s := &junitScenario{c: &junitTestCase{Name: e.Message, ClassName: e.Test}}
+		j.cases = append(j.cases, s.c)
+		j.running[e.Test] = s
+		return
+	}
+
+	s, ok := j.running[e.Test]
+	if !ok {
+		return
+	}
+	switch e.Kind {
+	case EventStep:
+		fmt.Fprintf(&s.steps, "%s %s\n", e.Keyword, e.Message)
+	case EventAssertion:
+		if e.Status == StatusFailed {
+			s.fail(e.Message, "assertion", e.Message)
+		}
+	case EventMismatch:
+		if e.Mismatch != nil {
+			s.fail(e.Mismatch.String(), "mismatch", mismatchText([]Mismatch{*e.Mismatch}))
+		}
+	case EventScenarioEnd:
+		s.c.Time = e.Duration.Seconds()
+		switch e.Status {
+		case StatusFailed:
+			s.fail("failed, see the test output for details", "failure", "")
+		case StatusSkipped:
+			s.c.Skipped = &struct{}{}
+		}
+		if s.c.Failure != nil {
+			s.c.Failure.Body = s.steps.String() + "\n" + s.c.Failure.Body
+		}
+		delete(j.running, e.Test)
+	}
+}
+
+func (s *junitScenario) fail(message, kind, body string) {
+	if s.c.Failure == nil {
+		s.c.Failure = &junitFailure{Message: message, Type: kind}
+	}
+	s.c.Failure.Body += body
+}
+
+// Close writes the test cases.
+func (j *JUnitScenarios) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	suite := junitTestSuite{
+		Name:      "scenarios",
+		Time:      time.Since(j.started).Seconds(),
+		Timestamp: j.started.UTC().Format("2006-01-02T15:04:05"),
+	}
+	for _, c := range j.cases {
+		suite.add(*c)
+	}
+	return writeJUnitXML(j.w, junitTestSuites{
+		Name:     "project-brian",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	})
+}
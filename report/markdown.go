@@ -0,0 +1,108 @@
+package report
+
+import (
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type markdownSeries struct {
+	Index int
+	Series
+	Count int
+	Top   []Mismatch
+}
+
+type markdownDataset struct {
+	*Dataset
+	Other   []Mismatch
+	Failing []markdownSeries
+}
+
+// NewMarkdown returns a reporter writing a Markdown summary of the datasets of the run to w when it is closed.
+func NewMarkdown(w io.Writer, run *Run, maxDiffs int) *RunReporter {
+	return NewRunReporter(w, run, func(w io.Writer, run *Run) error {
+		return WriteMarkdown(w, run, maxDiffs)
+	})
+}
+
+// WriteMarkdown writes a summary of the run as Markdown for a pull request comment: a table with a row per dataset,
+// then a collapsible section per failed dataset with the first maxDiffs diffs of each mismatching series. A negative
+// maxDiffs shows no diffs, like 0.
+func WriteMarkdown(w io.Writer, run *Run, maxDiffs int) error {
+	if maxDiffs < 0 {
+		maxDiffs = 0
+	}
+	top := func(mismatches []Mismatch) []Mismatch {
+		if len(mismatches) > maxDiffs {
+			return mismatches[:maxDiffs]
+		}
+		return mismatches
+	}
+
+	var datasets []markdownDataset
+	for _, d := range run.Datasets {
+		md := markdownDataset{Dataset: d, Other: top(d.SeriesMismatches(-1))}
+		for i, s := range d.Series {
+			if mismatches := d.SeriesMismatches(i); len(mismatches) > 0 {
+				md.Failing = append(md.Failing, markdownSeries{Index: i, Series: s, Count: len(mismatches), Top: top(mismatches)})
+			}
+		}
+		datasets = append(datasets, md)
+	}
+
+	return markdownTemplate.Execute(w, struct {
+		Run      *Run
+		Datasets []markdownDataset
+	}{run, datasets})
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"emoji": func(s Status) string {
+		switch s {
+		case StatusPassed:
+			return "✅"
+		case StatusFailed:
+			return "❌"
+		}
+		return "⏭️"
+	},
+	"duration": func(d time.Duration) time.Duration {
+		return d.Round(time.Millisecond)
+	},
+	"trim": func(s string) string {
+		return strings.TrimRight(s, "\n")
+	},
+	"oneLine": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+	},
+}).Parse(`### ConvertCSDB against {{.Run.Host}}{{if .Run.Version}} {{.Run.Version}}{{end}}
+
+| | Dataset | Mismatches | Accepted | Warnings | Duration |
+|---|---|---:|---:|---:|---:|
+{{range .Datasets}}| {{emoji .Status}} | {{.Name}}.csdb | {{len .Mismatches}} | {{len .Accepted}} | {{len .Warnings}} | {{duration .Duration}} |
+{{end}}
+{{- range .Datasets}}{{if eq .Status "failed"}}
+<details>
+<summary>{{emoji .Status}} {{.Name}}.csdb: {{oneLine .Failure}}</summary>
+{{range .Other}}
+**{{.Location}}**: {{.Reason}}
+{{if .Diff}}
+` + "```diff" + `
+{{trim .Diff}}
+` + "```" + `
+{{end}}{{end}}
+{{- range .Failing}}
+#### timeseries[{{.Index}}] {{.CDID}}: {{.Title}}
+
+{{.Count}} mismatch{{if gt .Count 1}}es{{end}}{{if lt (len .Top) .Count}}, showing the first {{len .Top}}{{end}}.
+{{range .Top}}
+**{{.Location}}**: {{.Reason}}
+{{if .Diff}}
+` + "```diff" + `
+{{trim .Diff}}
+` + "```" + `
+{{end}}{{end}}{{end}}
+</details>
+{{end}}{{end}}`))
@@ -0,0 +1,165 @@
+// Package report collects the results of a run of the brian tests so they can be written in formats other tools
+// understand.
+package report
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
+)
+
+// Status of a dataset or series.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Run is the result of converting every dataset with the brian at Host. Version is empty if it is not known.
+type Run struct {
+	Host     string
+	Version  string
+	Started  time.Time
+	Duration time.Duration
+	Datasets []*Dataset
+
+	mutex sync.Mutex
+}
+
+// NewRun starts a run against the brian host.
+func NewRun(host string) *Run {
+	return &Run{Host: host, Started: time.Now()}
+}
+
+// Dataset starts recording the result of converting a dataset as part of the run.
+func (r *Run) Dataset(name string) *Dataset {
+	d := NewDataset(name)
+	r.Add(d)
+	return d
+}
+
+// Add adds the result of converting a dataset to the run.
+func (r *Run) Add(d *Dataset) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Datasets = append(r.Datasets, d)
+}
+
+// Finish records the duration of the run.
+func (r *Run) Finish() {
+	r.Duration = time.Since(r.Started)
+}
+
+// Failed returns the number of datasets that failed.
+func (r *Run) Failed() int {
+	failed := 0
+	for _, d := range r.Datasets {
+		if d.Status == StatusFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Dataset is the result of converting a single CSDB file.
+type Dataset struct {
+	Name       string
+	Status     Status
+	Duration   time.Duration
+	Failure    string
+	Series     []Series
+	ValueCount int
+	Mismatches []Mismatch
+	// Accepted are the differences accepted by a tolerance, which do not fail the dataset.
+	Accepted []Mismatch
+	// Warnings are data-quality problems, such as values that do not aggregate, which do not fail the dataset.
+	Warnings []Mismatch
+
+	started time.Time
+}
+
+// NewDataset starts recording the result of converting a dataset.
+func NewDataset(name string) *Dataset {
+	return &Dataset{Name: name, Status: StatusPassed, started: time.Now()}
+}
+
+// Series identifies an expected time series of a dataset. Expected and Actual are only set for series with
+// mismatches.
+type Series struct {
+	CDID  string
+	Title string
+	Unit  string
+
+	Expected *timeseries.TimeSeries
+	Actual   *timeseries.TimeSeries
+}
+
+// Mismatch is a difference between the actual and expected response.
+type Mismatch struct {
+	// Series is the index of the time series in the response, or -1 if the mismatch is not specific to a series.
+	Series int `json:"series"`
+	// Location is the path of the mismatch in the response, e.g. timeseries[3].months[12].
+	Location string `json:"location"`
+	// Fields lists the JSON fields that differ, e.g. months.value.
+	Fields []string `json:"fields,omitempty"`
+	Reason string   `json:"reason"`
+	// Diff is a plain text JSON diff of the actual and expected values.
+	Diff string `json:"diff,omitempty"`
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s", m.Location, m.Reason)
+}
+
+// Fail marks the dataset as failed. Only the first failure message is kept.
+func (d *Dataset) Fail(format string, args ...interface{}) {
+	d.Status = StatusFailed
+	if d.Failure == "" {
+		d.Failure = fmt.Sprintf(format, args...)
+	}
+}
+
+// AddMismatches records mismatches, failing the dataset with the first one.
+func (d *Dataset) AddMismatches(mismatches ...Mismatch) {
+	if len(mismatches) == 0 {
+		return
+	}
+	d.Mismatches = append(d.Mismatches, mismatches...)
+	d.Fail("%s", mismatches[0])
+}
+
+// AddAccepted records differences accepted by a tolerance.
+func (d *Dataset) AddAccepted(accepted ...Mismatch) {
+	d.Accepted = append(d.Accepted, accepted...)
+}
+
+// AddWarnings records data-quality warnings.
+func (d *Dataset) AddWarnings(warnings ...Mismatch) {
+	d.Warnings = append(d.Warnings, warnings...)
+}
+
+// SeriesMismatches returns the mismatches of the time series at index i.
+func (d *Dataset) SeriesMismatches(i int) []Mismatch {
+	var mismatches []Mismatch
+	for _, m := range d.Mismatches {
+		if m.Series == i {
+			mismatches = append(mismatches, m)
+		}
+	}
+	return mismatches
+}
+
+// Finish records the duration and final status of the dataset.
+func (d *Dataset) Finish(failed, skipped bool) {
+	d.Duration = time.Since(d.started)
+	switch {
+	case failed:
+		d.Fail("failed, see the test output for details")
+	case skipped:
+		d.Status = StatusSkipped
+	}
+}
@@ -0,0 +1,246 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRun returns a run with a passing, failing and skipped dataset.
+func testRun() *Run {
+	run := NewRun("http://localhost:8083")
+
+	ott := run.Dataset("ott")
+	ott.Series = []Series{{CDID: "GMAA", Title: "OS visits to UK"}, {CDID: "GMAF", Title: "UK visits abroad"}}
+	ott.ValueCount = 100
+	ott.Finish(false, false)
+
+	berd := run.Dataset("berd")
+	berd.Series = []Series{{CDID: "A8E3", Title: "R&D <total>"}, {CDID: "DLBV", Title: "R&D"}}
+	berd.AddMismatches(
+		Mismatch{Series: 1, Location: "timeseries[1].months[12]", Fields: []string{"months.value"}, Reason: "actual did not match expected", Diff: "-  \"value\": \"1\",\n+  \"value\": \"2\","},
+		Mismatch{Series: 1, Location: "timeseries[1].months[13]", Fields: []string{"months.value", "months.date"}, Reason: "actual did not match expected"},
+	)
+	berd.Finish(true, false)
+
+	sppi := run.Dataset("sppi")
+	sppi.Finish(false, true)
+
+	run.Finish()
+	return run
+}
+
+func TestDataset_Status(t *testing.T) {
+	run := testRun()
+	assert.Equal(t, StatusPassed, run.Datasets[0].Status)
+	assert.Equal(t, StatusFailed, run.Datasets[1].Status)
+	assert.Equal(t, "timeseries[1].months[12]: actual did not match expected", run.Datasets[1].Failure)
+	assert.Equal(t, StatusSkipped, run.Datasets[2].Status)
+	assert.Equal(t, 1, run.Failed())
+	assert.Len(t, run.Datasets[1].SeriesMismatches(1), 2)
+	assert.Empty(t, run.Datasets[1].SeriesMismatches(0))
+}
+
+func TestWriteJUnit(t *testing.T) {
+	for _, perSeries := range []bool{false, true} {
+		var b bytes.Buffer
+		require.Nil(t, WriteJUnit(&b, testRun(), perSeries))
+
+		var suites junitTestSuites
+		require.Nil(t, xml.Unmarshal(b.Bytes(), &suites))
+		assert.Equal(t, 1, suites.Skipped)
+		assert.NotContains(t, b.String(), "\x1b[", "report should not contain colour escape codes")
+
+		if !perSeries {
+			assert.Equal(t, 3, suites.Tests)
+			assert.Equal(t, 1, suites.Failures)
+			require.Len(t, suites.Suites, 1)
+			assert.Contains(t, suites.Suites[0].Cases[1].Failure.Body, "Location: timeseries[1].months[13]")
+			continue
+		}
+
+		require.Len(t, suites.Suites, 3)
+		berd := suites.Suites[1]
+		assert.Equal(t, 3, berd.Tests)
+		assert.Equal(t, 2, berd.Failures, "the response and second series should fail")
+		assert.Nil(t, berd.Cases[1].Failure)
+		assert.True(t, strings.HasPrefix(berd.Cases[2].Failure.Message, "timeseries[1].months[12]"))
+		assert.Contains(t, berd.Cases[2].Failure.Body, "+  \"value\": \"2\",")
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	run := testRun()
+	berd := run.Datasets[1]
+	berd.Series[1].Title = "R&D <total>"
+	berd.Series[1].Expected = &timeseries.TimeSeries{Months: []timeseries.TimeSeriesValue{
+		{Date: "1980 JAN", Value: "1"}, {Date: "1980 FEB", Value: "2"}, {Date: "1980 MAR", Value: "3"},
+	}}
+	berd.Series[1].Actual = &timeseries.TimeSeries{Months: []timeseries.TimeSeriesValue{
+		{Date: "1980 JAN", Value: "1"}, {Date: "1980 FEB", Value: "5"}, {Date: "1980 APR", Value: "4"},
+	}}
+
+	var b bytes.Buffer
+	require.Nil(t, WriteHTML(&b, run))
+	html := b.String()
+
+	assert.Contains(t, html, `<h2 id="berd">berd.csdb</h2>`)
+	assert.NotContains(t, html, `<h2 id="ott">`, "passing datasets should only be in the summary")
+	assert.Contains(t, html, "R&amp;D &lt;total&gt;", "titles should be escaped")
+	assert.Contains(t, html, "months: 3 of 4 periods differ")
+	assert.Contains(t, html, `<tr class="changed"><td class="text">1980 FEB</td><td>2</td><td>5</td>`)
+	assert.Contains(t, html, `<tr><td class="text">1980 JAN</td>`)
+	assert.Contains(t, html, "missing from actual")
+	assert.Contains(t, html, "not expected")
+	assert.Contains(t, html, `<svg class="sparkline"`)
+	assert.NotContains(t, html, "<script", "report should be self-contained")
+
+	run = testRun()
+	run.Datasets[0].AddWarnings(Mismatch{Series: 0, Location: "timeseries[0].years[2].value", Reason: "1992 is 26 but the sum of its quarters is 27 (exact)"})
+	b.Reset()
+	require.Nil(t, WriteHTML(&b, run))
+	assert.Contains(t, b.String(), `<h2 id="ott">`, "passing datasets with warnings should be listed")
+	assert.Contains(t, b.String(), "<li>timeseries[0].years[2].value: 1992 is 26 but the sum of its quarters is 27 (exact)</li>")
+}
+
+func TestWriteSummary(t *testing.T) {
+	run := testRun()
+	run.Version = "1.2.0"
+	run.Datasets[0].AddAccepted(
+		Mismatch{Series: 0, Location: "timeseries[0].years[3]", Fields: []string{"years.value"}, Reason: `value "1.50" accepted as "1.5" with numeric`},
+		Mismatch{Series: 1, Location: "timeseries[1].years[0]", Fields: []string{"years.value"}, Reason: `value "10" accepted as "10.0" with numeric`},
+	)
+	run.Datasets[0].AddWarnings(Mismatch{Series: 1, Location: "timeseries[1].years[2].value", Fields: []string{"years.value"}, Reason: "1992 is 26 but the sum of its quarters is 27 (exact)"})
+
+	var b bytes.Buffer
+	require.Nil(t, WriteSummary(&b, run, 1))
+
+	var s summary
+	require.Nil(t, json.Unmarshal(b.Bytes(), &s))
+	assert.Equal(t, brianSummary{Host: "http://localhost:8083", Version: "1.2.0"}, s.Brian)
+	assert.Equal(t, 1, s.Passed)
+	assert.Equal(t, 1, s.Failed)
+	assert.Equal(t, 1, s.Skipped)
+	require.Len(t, s.Datasets, 3)
+
+	ott := s.Datasets[0]
+	assert.Equal(t, StatusPassed, ott.Status)
+	assert.Equal(t, 2, ott.SeriesCount)
+	assert.Equal(t, 100, ott.ValueCount)
+	assert.Empty(t, ott.Mismatches)
+	assert.Equal(t, StatusPassed, ott.Status, "accepted differences should not fail the dataset")
+	assert.Equal(t, 2, ott.AcceptedCount)
+	require.Len(t, ott.Accepted, 1, "only the first accepted difference should be listed")
+	assert.Equal(t, "GMAA", ott.Accepted[0].CDID)
+	assert.Equal(t, 1, ott.WarningCount)
+	require.Len(t, ott.Warnings, 1)
+	assert.Equal(t, "GMAF", ott.Warnings[0].CDID)
+
+	berd := s.Datasets[1]
+	assert.Equal(t, 2, berd.MismatchCount)
+	assert.Equal(t, map[string]int{"months.value": 2, "months.date": 1}, berd.MismatchesByField)
+	require.Len(t, berd.Mismatches, 1, "only the first mismatch should be listed")
+	assert.Equal(t, "timeseries[1].months[12]", berd.Mismatches[0].Location)
+	assert.Equal(t, "DLBV", berd.Mismatches[0].CDID)
+
+	b.Reset()
+	require.Nil(t, WriteSummary(&b, run, -1))
+	require.Nil(t, json.Unmarshal(b.Bytes(), &s))
+	assert.Len(t, s.Datasets[1].Mismatches, 2)
+	assert.Len(t, s.Datasets[0].Accepted, 2)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	run := testRun()
+	run.Datasets[0].AddAccepted(Mismatch{Series: 0, Location: "timeseries[0].years[3]", Reason: "accepted"})
+	require.Nil(t, WriteMarkdown(&b, run, 1))
+	md := b.String()
+
+	assert.Contains(t, md, "| ✅ | ott.csdb | 0 | 1 | 0 |")
+	assert.Contains(t, md, "| ❌ | berd.csdb | 2 |")
+	assert.Contains(t, md, "| ⏭️ | sppi.csdb | 0 |")
+	assert.Contains(t, md, "<summary>❌ berd.csdb: timeseries[1].months[12]: actual did not match expected</summary>")
+	assert.Contains(t, md, "#### timeseries[1] DLBV: R&D\n\n2 mismatches, showing the first 1.")
+	assert.Contains(t, md, "```diff\n-  \"value\": \"1\",\n+  \"value\": \"2\",\n```")
+	assert.NotContains(t, md, "timeseries[1].months[13]")
+	assert.NotContains(t, md, "\x1b[", "summary should not contain colour escape codes")
+
+	b.Reset()
+	require.Nil(t, WriteMarkdown(&b, run, -1))
+	assert.Contains(t, b.String(), "#### timeseries[1] DLBV: R&D\n\n2 mismatches, showing the first 0.")
+}
+
+func TestReporters(t *testing.T) {
+	var console, lines, junit, scenarios bytes.Buffer
+	reporters := Reporters{NewConsole(&console, false), NewJSONLines(&lines), NewJUnit(&junit, NewRun("http://localhost:8083"), false),
+		NewJUnitScenarios(&scenarios)}
+
+	mismatch := Mismatch{Series: 1, Location: "timeseries[1].months[12]", Reason: "actual did not match expected"}
+	ott, berd := NewDataset("ott"), NewDataset("berd")
+	ott.Finish(false, false)
+	berd.AddMismatches(mismatch)
+	berd.Finish(true, false)
+	for _, e := range []Event{
+		{Kind: EventScenario, Test: "TestA", Message: "ott converts"},
+		{Kind: EventScenario, Test: "TestB", Message: "berd converts"},
+		{Kind: EventStep, Test: "TestA", Keyword: "Given", Message: "a valid ott.csdb file"},
+		{Kind: EventStep, Test: "TestB", Keyword: "Given", Message: "a valid berd.csdb file"},
+		{Kind: EventLog, Test: "TestB", Keyword: "warning", Message: "slow"},
+		{Kind: EventTiming, Test: "TestB", Message: "POST", Duration: time.Second},
+		{Kind: EventAssertion, Test: "TestA", Message: "status is 200", Status: StatusPassed},
+		{Kind: EventMismatch, Test: "TestB", Mismatch: &mismatch},
+		{Kind: EventAssertion, Test: "TestB", Message: "1 mismatch", Status: StatusFailed},
+		{Kind: EventDataset, Test: "TestA", Message: "ott", Status: ott.Status, Dataset: ott},
+		{Kind: EventScenarioEnd, Test: "TestA", Status: StatusPassed, Duration: time.Second},
+		{Kind: EventDataset, Test: "TestB", Message: "berd", Status: berd.Status, Dataset: berd},
+		{Kind: EventScenarioEnd, Test: "TestB", Status: StatusFailed, Duration: 2 * time.Second},
+	} {
+		reporters.Report(e)
+	}
+	require.Nil(t, reporters.Close())
+
+	assert.Equal(t, "Scenario: ott converts\nScenario: berd converts\nGiven: a valid ott.csdb file\n"+
+		"Given: a valid berd.csdb file\nwarning: slow\n", console.String())
+
+	events := strings.Split(strings.TrimSpace(lines.String()), "\n")
+	require.Len(t, events, 13)
+	var e Event
+	require.Nil(t, json.Unmarshal([]byte(events[7]), &e))
+	assert.Equal(t, EventMismatch, e.Kind)
+	assert.Equal(t, mismatch, *e.Mismatch)
+	require.Nil(t, json.Unmarshal([]byte(events[5]), &e))
+	assert.Equal(t, time.Second, e.Duration)
+	var dataset Event
+	require.Nil(t, json.Unmarshal([]byte(events[11]), &dataset))
+	assert.Equal(t, Event{Kind: EventDataset, Test: "TestB", Message: "berd", Status: StatusFailed}, dataset)
+
+	var suites junitTestSuites
+	require.Nil(t, xml.Unmarshal(junit.Bytes(), &suites))
+	assert.Equal(t, 2, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	cases := suites.Suites[0].Cases
+	require.Len(t, cases, 2)
+	assert.Equal(t, "ott.csdb", cases[0].Name)
+	assert.Nil(t, cases[0].Failure)
+	require.NotNil(t, cases[1].Failure)
+	assert.Equal(t, "timeseries[1].months[12]: actual did not match expected", cases[1].Failure.Message)
+
+	suites = junitTestSuites{}
+	require.Nil(t, xml.Unmarshal(scenarios.Bytes(), &suites))
+	assert.Equal(t, 2, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	cases = suites.Suites[0].Cases
+	require.Len(t, cases, 2)
+	assert.Equal(t, junitTestCase{Name: "ott converts", ClassName: "TestA", Time: 1}, cases[0])
+	require.NotNil(t, cases[1].Failure)
+	assert.Equal(t, "timeseries[1].months[12]: actual did not match expected", cases[1].Failure.Message)
+	assert.True(t, strings.HasPrefix(cases[1].Failure.Body, "Given a valid berd.csdb file\n\nReason: actual did not match expected"))
+}
@@ -0,0 +1,141 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// EventKind is the kind of an Event.
+type EventKind string
+
+const (
+	// EventScenario starts a scenario, the Message describes it.
+	EventScenario EventKind = "scenario"
+	// EventScenarioEnd ends a scenario with its Status and Duration.
+	EventScenarioEnd EventKind = "scenario.end"
+	// EventStep is a Given, When, Then or And step of a scenario, the Keyword.
+	EventStep EventKind = "step"
+	// EventAssertion is the Status of a check described by the Message.
+	EventAssertion EventKind = "assertion"
+	// EventMismatch is a Mismatch between the actual and expected response.
+	EventMismatch EventKind = "mismatch"
+	// EventTiming is the Duration of the operation described by the Message.
+	EventTiming EventKind = "timing"
+	// EventLog is a message logged at the level in the Keyword, info or warning.
+	EventLog EventKind = "log"
+	// EventDataset is the finished result of converting the Dataset named in the Message, with its Status and
+	// Duration.
+	EventDataset EventKind = "dataset"
+)
+
+// Logger logs a message against a test, such as a *testing.T. Helper marks the calling function as a helper so the
+// message is attributed to the line of the test that reported the event.
+type Logger interface {
+	Helper()
+	Logf(format string, args ...interface{})
+}
+
+// Event is something that happened while running a test.
+type Event struct {
+	Kind     EventKind     `json:"kind"`
+	Time     time.Time     `json:"time"`
+	Test     string        `json:"test"`
+	Keyword  string        `json:"keyword,omitempty"`
+	Message  string        `json:"message,omitempty"`
+	Status   Status        `json:"status,omitempty"`
+	Duration time.Duration `json:"durationNanos,omitempty"`
+	Mismatch *Mismatch     `json:"mismatch,omitempty"`
+	Dataset  *Dataset      `json:"-"`
+
+	// Log is the logger of the test the event belongs to, if any. Reporters writing to the console use it so the
+	// output is shown against the test.
+	Log Logger `json:"-"`
+}
+
+// Reporter receives the events of a run. Report may be called from several goroutines at once.
+type Reporter interface {
+	Report(e Event)
+	// Close flushes anything the reporter has buffered once the run is over.
+	Close() error
+}
+
+// Reporters reports every event to each of the reporters.
+type Reporters []Reporter
+
+// Report sends the event to each reporter in turn.
+func (rs Reporters) Report(e Event) {
+	if e.Log != nil {
+		e.Log.Helper()
+	}
+	for _, r := range rs {
+		r.Report(e)
+	}
+}
+
+// Close closes every reporter, returning the first error.
+func (rs Reporters) Close() error {
+	var first error
+	for _, r := range rs {
+		if err := r.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// JSONLines writes each event as a line of JSON.
+type JSONLines struct {
+	mutex sync.Mutex
+	enc   *json.Encoder
+	err   error
+}
+
+// NewJSONLines returns a reporter writing JSON lines to w.
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{enc: json.NewEncoder(w)}
+}
+
+// Report writes the event. Once a write has failed the remaining events are dropped and Close returns the error.
+func (j *JSONLines) Report(e Event) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.err == nil {
+		j.err = j.enc.Encode(e)
+	}
+}
+
+// Close returns the first error writing an event.
+func (j *JSONLines) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.err
+}
+
+// RunReporter collects the datasets of a run from their EventDataset events, and writes the run with a report format
+// such as WriteHTML once it is closed.
+type RunReporter struct {
+	w     io.Writer
+	run   *Run
+	write func(w io.Writer, run *Run) error
+}
+
+// NewRunReporter returns a reporter adding each dataset to the run, then writing it to w when it is closed.
+func NewRunReporter(w io.Writer, run *Run, write func(w io.Writer, run *Run) error) *RunReporter {
+	return &RunReporter{w: w, run: run, write: write}
+}
+
+// Report adds the dataset of an EventDataset to the run.
+func (r *RunReporter) Report(e Event) {
+	if e.Kind == EventDataset && e.Dataset != nil {
+		r.run.Add(e.Dataset)
+	}
+}
+
+// Close finishes the run and writes it.
+func (r *RunReporter) Close() error {
+	r.run.Finish()
+	return r.write(r.w, r.run)
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type summary struct {
+	Brian           brianSummary     `json:"brian"`
+	Started         time.Time        `json:"started"`
+	DurationSeconds float64          `json:"durationSeconds"`
+	Passed          int              `json:"passed"`
+	Failed          int              `json:"failed"`
+	Skipped         int              `json:"skipped"`
+	Datasets        []datasetSummary `json:"datasets"`
+}
+
+type brianSummary struct {
+	Host    string `json:"host"`
+	Version string `json:"version,omitempty"`
+}
+
+type datasetSummary struct {
+	Name              string            `json:"name"`
+	Status            Status            `json:"status"`
+	DurationSeconds   float64           `json:"durationSeconds"`
+	Failure           string            `json:"failure,omitempty"`
+	SeriesCount       int               `json:"seriesCount"`
+	ValueCount        int               `json:"valueCount"`
+	MismatchCount     int               `json:"mismatchCount"`
+	MismatchesByField map[string]int    `json:"mismatchesByField"`
+	Mismatches        []mismatchSummary `json:"mismatches"`
+	AcceptedCount     int               `json:"acceptedCount"`
+	Accepted          []mismatchSummary `json:"accepted"`
+	WarningCount      int               `json:"warningCount"`
+	Warnings          []mismatchSummary `json:"warnings"`
+}
+
+type mismatchSummary struct {
+	Location string   `json:"location"`
+	CDID     string   `json:"cdid,omitempty"`
+	Fields   []string `json:"fields,omitempty"`
+	Reason   string   `json:"reason"`
+	Diff     string   `json:"diff,omitempty"`
+}
+
+// NewSummary returns a reporter writing a JSON summary of the datasets of the run to w when it is closed.
+func NewSummary(w io.Writer, run *Run, maxMismatches int) *RunReporter {
+	return NewRunReporter(w, run, func(w io.Writer, run *Run) error {
+		return WriteSummary(w, run, maxMismatches)
+	})
+}
+
+// WriteSummary writes the run as JSON for other tools to consume. Each dataset lists its first maxMismatches
+// mismatches and the number of mismatches of each field, its first maxMismatches differences accepted by a
+// tolerance and its first maxMismatches data-quality warnings; a negative maxMismatches lists every one.
+func WriteSummary(w io.Writer, run *Run, maxMismatches int) error {
+	s := summary{
+		Brian:           brianSummary{Host: run.Host, Version: run.Version},
+		Started:         run.Started.UTC(),
+		DurationSeconds: run.Duration.Seconds(),
+		Datasets:        []datasetSummary{},
+	}
+
+	for _, d := range run.Datasets {
+		switch d.Status {
+		case StatusPassed:
+			s.Passed++
+		case StatusFailed:
+			s.Failed++
+		case StatusSkipped:
+			s.Skipped++
+		}
+		s.Datasets = append(s.Datasets, summariseDataset(d, maxMismatches))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func summariseDataset(d *Dataset, maxMismatches int) datasetSummary {
+	ds := datasetSummary{
+		Name:              d.Name,
+		Status:            d.Status,
+		DurationSeconds:   d.Duration.Seconds(),
+		Failure:           d.Failure,
+		SeriesCount:       len(d.Series),
+		ValueCount:        d.ValueCount,
+		MismatchCount:     len(d.Mismatches),
+		MismatchesByField: make(map[string]int),
+		Mismatches:        summariseMismatches(d, d.Mismatches, maxMismatches),
+		AcceptedCount:     len(d.Accepted),
+		Accepted:          summariseMismatches(d, d.Accepted, maxMismatches),
+		WarningCount:      len(d.Warnings),
+		Warnings:          summariseMismatches(d, d.Warnings, maxMismatches),
+	}
+
+	for _, m := range d.Mismatches {
+		for _, f := range m.Fields {
+			ds.MismatchesByField[f]++
+		}
+	}
+	return ds
+}
+
+func summariseMismatches(d *Dataset, mismatches []Mismatch, max int) []mismatchSummary {
+	summaries := []mismatchSummary{}
+	for i, m := range mismatches {
+		if max >= 0 && i >= max {
+			break
+		}
+		ms := mismatchSummary{Location: m.Location, Fields: m.Fields, Reason: m.Reason, Diff: m.Diff}
+		if m.Series >= 0 && m.Series < len(d.Series) {
+			ms.CDID = d.Series[m.Series].CDID
+		}
+		summaries = append(summaries, ms)
+	}
+	return summaries
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/ONSdigital/project-brian-api-test/report"
+	"github.com/ONSdigital/project-brian-api-test/timeseries"
+	"github.com/logrusorgru/aurora"
+)
+
+var (
+	junitPath     = flag.String("junit", "", "write a JUnit XML report of the CSDB conversions to this file")
+	junitSeries   = flag.Bool("junit.series", false, "include a test case for every time series in the JUnit report")
+	htmlPath      = flag.String("html", "", "write an HTML report comparing the expected and actual values of mismatching series to this file")
+	markdownPath  = flag.String("markdown", "", "write a Markdown summary of the run for a pull request comment to this file")
+	markdownDiffs = flag.Int("markdown.diffs", 3, "the number of diffs shown per mismatching series in the Markdown summary")
+	eventsPath    = flag.String("events", "", "write every test event as a line of JSON to this file")
+	scenariosPath = flag.String("junit.scenarios", "", "write a JUnit XML report with a test case per scenario to this file")
+	summaryPath   = flag.String("summary", "summary.json", "write a JSON summary of the run to this file, empty to disable")
+	summaryMax    = flag.Int("summary.mismatches", 10, "the number of mismatches listed per dataset in the JSON summary, -1 for all")
+)
+
+// reporter receives the scenarios, steps, assertions, mismatches and timings of every test.
+var reporter report.Reporter
+
+// colourOutput is whether the console output is coloured.
+var colourOutput bool
+
+// colours colour the failure messages of the tests, only if the console output is coloured.
+var colours = aurora.NewAurora(false)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	if *tolerancePath != "" {
+		rules, err := timeseries.ReadToleranceRules(*tolerancePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading tolerance rules: %s\n", err)
+			os.Exit(1)
+		}
+		toleranceRules = rules
+	}
+	if *fieldsPath != "" {
+		rules, err := timeseries.ReadFieldRules(*fieldsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading field rules: %s\n", err)
+			os.Exit(1)
+		}
+		fieldRules = rules
+	}
+	if *aggregationPath != "" {
+		rules, err := timeseries.ReadAggregationRules(*aggregationPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading aggregation rules: %s\n", err)
+			os.Exit(1)
+		}
+		aggregationRules = rules
+	}
+
+	colourOutput = report.ColourEnabled(os.Stdout)
+	colours = aurora.NewAurora(colourOutput)
+	reporters, files, err := newReporters()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error creating reporters: %s\n", err)
+		os.Exit(1)
+	}
+	reporter = reporters
+
+	code := m.Run()
+
+	err = reporters.Close()
+	for _, f := range files {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error writing reports: %s\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// newReporters returns the console reporter along with a reporter for each report file requested, and the files to
+// close once the reporters are closed. Every report format is a reporter: those summarising the run collect the
+// result of each dataset from its events and write the file when they are closed.
+func newReporters() (report.Reporters, []*os.File, error) {
+	reporters := report.Reporters{report.NewConsole(os.Stdout, colourOutput)}
+	var files []*os.File
+
+	for _, r := range []struct {
+		path *string
+		new  func(w io.Writer) report.Reporter
+	}{
+		{eventsPath, func(w io.Writer) report.Reporter { return report.NewJSONLines(w) }},
+		{scenariosPath, func(w io.Writer) report.Reporter { return report.NewJUnitScenarios(w) }},
+		{junitPath, func(w io.Writer) report.Reporter { return report.NewJUnit(w, newRun(), *junitSeries) }},
+		{htmlPath, func(w io.Writer) report.Reporter { return report.NewHTML(w, newRun()) }},
+		{markdownPath, func(w io.Writer) report.Reporter { return report.NewMarkdown(w, newRun(), *markdownDiffs) }},
+		{summaryPath, func(w io.Writer) report.Reporter { return report.NewSummary(w, newRun(), *summaryMax) }},
+	} {
+		if *r.path == "" {
+			continue
+		}
+		f, err := os.Create(*r.path)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, f)
+		reporters = append(reporters, r.new(f))
+	}
+	return reporters, files, nil
+}
+
+// newRun starts a run against the brian under test.
+func newRun() *report.Run {
+	run := report.NewRun(brianHost)
+	run.Version = brianVersion
+	return run
+}
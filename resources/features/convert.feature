@@ -0,0 +1,72 @@
+Feature: Convert CSDB files to time series JSON
+  Brian converts each series of a CSDB file into time series JSON with its
+  years, quarters and months.
+
+  Scenario Outline: <dataset>.csdb converts to the golden JSON
+    Given the CSDB file <dataset>.csdb
+    When it is posted to /Services/ConvertCSDB
+    Then the response status is 200
+    And every time series satisfies the invariants
+    And every time series agrees with the CSDB file
+    And the blocks of each CDID are merged by seasonal adjustment
+    And the response matches golden <dataset>
+
+    Examples:
+      | dataset |
+      | ott     |
+      | berd    |
+
+  Scenario: The sourceDataset is the upload filename in upper case
+    Given the CSDB file ott.csdb
+    When it is posted to /Services/ConvertCSDB
+    Then the response status is 200
+    And every value has the sourceDataset OTT
+
+  Scenario: Annual and quarterly blocks of a CDID are merged into one series
+    Given a CSDB file named small.csdb containing:
+      """
+       02016 219OTT          3 4 1 1
+       1 1IDENTIFIER
+       1 2PERIODICITY
+       1 3SEASONAL ADJUSTMENT
+      92GMAAAU
+      93OS visits to UK:All visits Thousands-NSA
+      96AS1980  12015 818    3             710 0
+      97     12419     11451     11638
+      92GMAAQU
+      93OS visits to UK:All visits Thousands-NSA
+      96QS1980  12016 120    9             710 0
+      97      2081      3240      4738      2360      1920      3008      4261
+      97      2262      2013
+      """
+    When it is posted to /Services/ConvertCSDB
+    Then the response status is 200
+    And the response contains 1 time series
+    And the time series GMAA has 3 years
+    And the time series GMAA has 9 quarters
+    And every time series satisfies the invariants
+    And every time series agrees with the CSDB file
+    And the blocks of each CDID are merged by seasonal adjustment
+
+  Scenario: Blocks of a CDID with different seasonal adjustments are not merged
+    Given a CSDB file named adjusted.csdb containing:
+      """
+       02016 219OTT          3 4 1 1
+       1 1IDENTIFIER
+       1 2PERIODICITY
+       1 3SEASONAL ADJUSTMENT
+      92GMATAU
+      93OS visits to the UK: Thousands - SA
+      96AS1980  12015 818    3             710 0
+      97     12419     11451     11638
+      92GMATQA
+      93OS visits to the UK: Thousands - SA
+      96QS1980  12016 120    9             710 0
+      97      2081      3240      4738      2360      1920      3008      4261
+      97      2262      2013
+      """
+    When it is posted to /Services/ConvertCSDB
+    Then the response status is 200
+    And the response contains 2 time series
+    And every time series agrees with the CSDB file
+    And the blocks of each CDID are merged by seasonal adjustment
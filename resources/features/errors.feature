@@ -0,0 +1,13 @@
+Feature: Reject requests brian cannot convert
+  Requests without a usable CSDB file, or to a service that does not exist,
+  fail with an error status rather than an empty conversion.
+
+  Scenario: An empty file is rejected
+    Given an empty CSDB file named empty.csdb
+    When it is posted to /Services/ConvertCSDB
+    Then the response status is not 200
+
+  Scenario: An unknown service is not found
+    Given the CSDB file ott.csdb
+    When it is posted to /Services/ConvertNothing
+    Then the response status is 404
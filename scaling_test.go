@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/project-brian-api-test/perf"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	scaling        = flag.Bool("scaling", false, "convert increasingly large synthetic files and chart response time and size against input size")
+	scalingSource  = flag.String("scaling.source", "bb", "input file the synthetic files are built from")
+	scalingFactors = flag.String("scaling.factors", "0.25,0.5,1,2,4", "comma separated multiples of the source file's CDIDs to convert")
+	scalingOut     = flag.String("scaling.out", "resources/scaling", "directory the CSV and SVG reports are written to")
+	scalingTimeout = flag.Duration("scaling.timeout", 5*time.Minute, "timeout for each conversion")
+)
+
+type scalingResult struct {
+	factor        float64
+	series        int
+	inputBytes    int64
+	latency       time.Duration
+	responseBytes int64
+	status        int
+}
+
+func TestConvert_Scaling(t *testing.T) {
+	if !*scaling {
+		t.Skip("scaling report disabled, run with -scaling to enable")
+	}
+
+	factors, err := parseFactors(*scalingFactors)
+	require.Nil(t, err, Err("invalid -scaling.factors"))
+
+	Scenario(t, "Response time and size grow with the size of the input")
+
+	Given(t, fmt.Sprintf("synthetic files built from %s.csdb with %v times its CDIDs", *scalingSource, factors))
+	source := readCSDBFixture(t, *scalingSource)
+
+	When(t, "each file is posted to /Services/ConvertCSDB")
+	var results []scalingResult
+	for _, factor := range factors {
+		file, err := source.Scale(factor)
+		require.Nil(t, err, Err("error building synthetic file"))
+
+		data := file.Bytes()
+		result := scalingResult{factor: factor, series: len(file.Series), inputBytes: int64(len(data))}
+
+		body, contentType, err := newCSDBRequestBody(*scalingSource+".csdb", bytes.NewReader(data), result.inputBytes)
+		require.Nil(t, err, Err("error creating POST request"))
+
+		start := time.Now()
+		response, err := postCSDBFileWithTimeout(body, contentType, *scalingTimeout)
+		require.Nil(t, err, Err(fmt.Sprintf("error converting %v x %s.csdb", factor, *scalingSource)))
+		result.responseBytes, err = io.Copy(ioutil.Discard, response.Body)
+		response.Body.Close()
+		require.Nil(t, err, Err("error reading csdb response"))
+
+		result.latency = time.Since(start)
+		result.status = response.StatusCode
+		info(t, fmt.Sprintf("%v x %s.csdb: %d series, %d bytes in, %d bytes out in %s (status %d)",
+			factor, *scalingSource, result.series, result.inputBytes, result.responseBytes, result.latency.Round(time.Millisecond), result.status))
+		results = append(results, result)
+	}
+
+	Then(t, fmt.Sprintf("the results are written to %s", *scalingOut))
+	require.Nil(t, os.MkdirAll(*scalingOut, os.ModePerm), Err("error creating output directory"))
+	require.Nil(t, writeScalingCSV(filepath.Join(*scalingOut, "scaling.csv"), results), Err("error writing CSV report"))
+	require.Nil(t, writeScalingSVG(filepath.Join(*scalingOut, "scaling.svg"), results), Err("error writing SVG report"))
+
+	for _, r := range results {
+		require.Equal(t, http.StatusOK, r.status, Err(fmt.Sprintf("incorrect http response status code for %v x %s.csdb", r.factor, *scalingSource)))
+	}
+	info(t, "Passed")
+}
+
+func parseFactors(s string) ([]float64, error) {
+	var factors []float64
+	for _, f := range strings.Split(s, ",") {
+		factor, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, err
+		}
+		factors = append(factors, factor)
+	}
+	return factors, nil
+}
+
+func writeScalingCSV(path string, results []scalingResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"factor", "series", "input_bytes", "response_ms", "response_bytes", "ms_per_input_mb", "status"})
+	for _, r := range results {
+		ms := float64(r.latency) / float64(time.Millisecond)
+		w.Write([]string{
+			strconv.FormatFloat(r.factor, 'f', -1, 64),
+			strconv.Itoa(r.series),
+			strconv.FormatInt(r.inputBytes, 10),
+			strconv.FormatFloat(ms, 'f', 1, 64),
+			strconv.FormatInt(r.responseBytes, 10),
+			strconv.FormatFloat(ms/(float64(r.inputBytes)/1e6), 'f', 1, 64),
+			strconv.Itoa(r.status),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeScalingSVG(path string, results []scalingResult) error {
+	latency := perf.Chart{Title: "Response time", XLabel: "input size (MB)", YLabel: "response time (ms)"}
+	size := perf.Chart{Title: "Response size", XLabel: "input size (MB)", YLabel: "response size (MB)"}
+	for _, r := range results {
+		x := float64(r.inputBytes) / 1e6
+		latency.Points = append(latency.Points, perf.Point{X: x, Y: float64(r.latency) / float64(time.Millisecond)})
+		size.Points = append(size.Points, perf.Point{X: x, Y: float64(r.responseBytes) / 1e6})
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return perf.WriteSVG(f, []perf.Chart{latency, size})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sourceDatasetScenario uploads the same bytes under a different filename. The sourceDataset brian reports is derived
+// from the uploaded filename: the upload name ott.csdb, like the name of every golden, becomes OTT. Path-like names
+// may be rejected with a 4xx status instead.
+//
+// An empty expected sourceDataset has not been captured from brian yet. The response must still be safe, but the
+// scenario is skipped, logging the sourceDataset brian returned, rather than asserting a guess.
+type sourceDatasetScenario struct {
+	uploadName string
+	expected   string
+	mayReject  bool
+}
+
+var sourceDatasetScenarios = []sourceDatasetScenario{
+	{uploadName: "ott.csdb", expected: "OTT"},
+	{uploadName: "OTT.csdb"},
+	{uploadName: "oTt.CSDB"},
+	{uploadName: "ott"},
+	{uploadName: "ott.v2.csdb"},
+	{uploadName: "öttö.csdb"},
+	{uploadName: "データ.csdb"},
+	{uploadName: "../x.csdb", mayReject: true},
+	{uploadName: "../../etc/ott.csdb", mayReject: true},
+	{uploadName: `..\x.csdb`, mayReject: true},
+	{uploadName: "/tmp/ott.csdb", mayReject: true},
+}
+
+func TestConvert_SourceDatasetFromFilename(t *testing.T) {
+	data, err := ioutil.ReadFile("resources/inputs/ott.csdb")
+	require.Nil(t, err, Err("error reading input file"))
+
+	for _, scenario := range sourceDatasetScenarios {
+		scenario := scenario
+		t.Run(scenario.uploadName, func(t *testing.T) {
+			testSourceDatasetFromFilename(t, scenario, data)
+		})
+	}
+}
+
+func testSourceDatasetFromFilename(t *testing.T, scenario sourceDatasetScenario, data []byte) {
+	Scenario(t, fmt.Sprintf("The sourceDataset is derived from the upload filename %q", scenario.uploadName))
+
+	Given(t, fmt.Sprintf("the ott.csdb file uploaded as %q", scenario.uploadName))
+	When(t, "a POST request is sent to /Services/ConvertCSDB")
+	response, err := postCSDBBytes(scenario.uploadName, data)
+	require.Nil(t, err, Err("error sending POST request"))
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	require.Nil(t, err, Err("error reading csdb response"))
+
+	Then(t, "nothing unsafe from the filename is echoed back")
+	assertNothingUnsafeEchoed(t, scenario.uploadName, string(body))
+
+	if scenario.mayReject && response.StatusCode >= 400 && response.StatusCode < 500 {
+		info(t, fmt.Sprintf("Passed: upload rejected with status %d", response.StatusCode))
+		return
+	}
+
+	And(t, "a 200 response status is returned")
+	require.Equal(t, http.StatusOK, response.StatusCode, Err("incorrect http response status code for POST CSDB request"))
+
+	var actual []TimeSeries
+	require.Nil(t, json.Unmarshal(body, &actual), Err("error reading csdb response json"))
+	require.NotEmpty(t, actual, Err("no time series returned"))
+
+	if scenario.expected == "" {
+		var sourceDatasets []string
+		seen := make(map[string]bool)
+		for _, ts := range actual {
+			for _, sourceDataset := range ts.SourceDatasets {
+				if !seen[sourceDataset] {
+					seen[sourceDataset] = true
+					sourceDatasets = append(sourceDatasets, sourceDataset)
+				}
+			}
+		}
+		t.Skipf("the sourceDataset of the upload name %q has not been captured from brian yet, it returned %q", scenario.uploadName, sourceDatasets)
+	}
+
+	And(t, fmt.Sprintf("every sourceDataset is %q", scenario.expected))
+	if unexpected := unexpectedSourceDataset(actual, scenario.expected); unexpected != "" {
+		t.Fatal(Err(unexpected))
+	}
+	info(t, "Passed")
+}
+
+// unexpectedSourceDataset describes the first sourceDatasets or sourceDataset of the time series that is not
+// expected, or returns "" if they all are.
+func unexpectedSourceDataset(actual []TimeSeries, expected string) string {
+	for i, ts := range actual {
+		if len(ts.SourceDatasets) != 1 || ts.SourceDatasets[0] != expected {
+			return fmt.Sprintf("timeseries[%d].sourceDatasets: expected [%q], got %q", i, expected, ts.SourceDatasets)
+		}
+
+		for _, field := range []struct {
+			name   string
+			values []TimeSeriesValue
+		}{{"years", ts.Years}, {"quarters", ts.Quarters}, {"months", ts.Months}} {
+			for j, v := range field.values {
+				if v.SourceDataset != expected {
+					return fmt.Sprintf("timeseries[%d].%s[%d].sourceDataset: expected %q, got %q", i, field.name, j, expected, v.SourceDataset)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// assertNothingUnsafeEchoed checks the response doesn't contain the directory parts of a path-like filename or
+// control characters.
+func assertNothingUnsafeEchoed(t *testing.T, uploadName, body string) {
+	if i := strings.LastIndexAny(uploadName, `/\`); i >= 0 {
+		dir := uploadName[:i+1]
+		assert.NotContains(t, body, dir, Err("response contains the directory part of the upload filename"))
+		assert.NotContains(t, body, strings.Replace(dir, `\`, `\\`, -1), Err("response contains the directory part of the upload filename"))
+	}
+
+	for _, r := range body {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			t.Fatalf(Err("response contains control character %U"), r)
+		}
+	}
+}
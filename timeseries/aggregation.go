@@ -0,0 +1,187 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+// AggregationMethod is how the quarters of a year, or months of a quarter, add up to its value.
+type AggregationMethod string
+
+const (
+	// Sum of the parts, e.g. visits.
+	Sum AggregationMethod = "sum"
+	// Mean of the parts, e.g. an index.
+	Mean AggregationMethod = "mean"
+)
+
+// Aggregation is how the values of a series aggregate, and the tolerance the aggregate is compared with. A value is
+// compared at its own precision, so the aggregate is rounded to its decimal places first.
+type Aggregation struct {
+	Method AggregationMethod `json:"method"`
+	Tolerance
+}
+
+func (a Aggregation) validate() error {
+	switch a.Method {
+	case "", Sum, Mean:
+	default:
+		return errors.Errorf("unknown aggregation method %q", a.Method)
+	}
+	return a.Tolerance.validate()
+}
+
+// AggregationRule applies an aggregation to the series matching every selector given, as a ToleranceRule does.
+type AggregationRule struct {
+	ToleranceRule
+	Method AggregationMethod `json:"method"`
+}
+
+// AggregationRules choose the aggregation of each series: the last rule matching the series, or the default.
+type AggregationRules struct {
+	Default Aggregation       `json:"default"`
+	Rules   []AggregationRule `json:"rules"`
+}
+
+// For returns the aggregation of the series with the CDID and unit in the dataset.
+func (r *AggregationRules) For(dataset, unit, cdid string) Aggregation {
+	a := r.Default
+	for _, rule := range r.Rules {
+		if rule.matches(dataset, unit, cdid) {
+			a = Aggregation{Method: rule.Method, Tolerance: rule.Tolerance}
+		}
+	}
+	return a
+}
+
+// ReadAggregationRules reads aggregation rules from a JSON file such as:
+//
+//	{
+//	  "default": {"method": "sum", "mode": "relative", "tolerance": 0.001},
+//	  "rules": [
+//	    {"dataset": "sppi", "method": "mean", "mode": "absolute", "tolerance": 0.1}
+//	  ]
+//	}
+func ReadAggregationRules(filename string) (*AggregationRules, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules AggregationRules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, errors.Wrapf(err, "error reading aggregation rules %s", filename)
+	}
+	if err := rules.Default.validate(); err != nil {
+		return nil, errors.Wrapf(err, "%s: default", filename)
+	}
+	for i, rule := range rules.Rules {
+		if err := (Aggregation{Method: rule.Method, Tolerance: rule.Tolerance}).validate(); err != nil {
+			return nil, errors.Wrapf(err, "%s: rules[%d]", filename, i)
+		}
+		if _, err := path.Match(rule.CDID, ""); err != nil {
+			return nil, errors.Wrapf(err, "%s: rules[%d]: cdid %q", filename, i, rule.CDID)
+		}
+	}
+	return &rules, nil
+}
+
+// Inconsistency is a year or quarter whose value does not agree with the aggregate of its quarters or months.
+type Inconsistency struct {
+	// Location of the value in its series, e.g. years[3].value.
+	Location  string
+	Period    Period
+	Value     string
+	Parts     string
+	Aggregate string
+	Aggregation
+}
+
+func (i Inconsistency) String() string {
+	method := i.Method
+	if method == "" {
+		method = Sum
+	}
+	return fmt.Sprintf("%s is %s but the %s of its %s is %s (%s)", i.Period, i.Value, method, i.Parts, i.Aggregate, i.Tolerance)
+}
+
+// CheckAggregation returns the years that do not agree with their four quarters, and the quarters that do not agree
+// with their three months. Periods with a part missing, blank or not a number are not checked.
+func CheckAggregation(ts TimeSeries, a Aggregation) []Inconsistency {
+	s, _ := ts.Parse()
+
+	var inconsistencies []Inconsistency
+	check := func(name string, totals []Observation, partsName string, parts []Observation) {
+		if len(totals) == 0 || len(parts) == 0 {
+			return
+		}
+		byOrdinal := make(map[int]*Decimal)
+		perYear := 0
+		for _, p := range parts {
+			if p.Value != nil && p.Period.Periodicity != 0 {
+				byOrdinal[p.Period.Ordinal()] = p.Value
+				perYear = p.Period.Periodicity.PerYear()
+			}
+		}
+
+		for i, total := range totals {
+			if total.Value == nil || total.Period.Periodicity == 0 {
+				continue
+			}
+			n := perYear / total.Period.Periodicity.PerYear()
+			first := total.Period.Year * perYear
+			if total.Period.Periodicity != Annual {
+				first += (total.Period.Index - 1) * n
+			}
+
+			aggregate, ok := aggregate(byOrdinal, first, n, a.Method)
+			if !ok {
+				continue
+			}
+			scale := total.Value.Scale()
+			if scale < 0 {
+				scale = 0
+			}
+			value := total.Value.String()
+			rounded := aggregate.FloatString(scale)
+			if !a.Equal(rounded, value) {
+				inconsistencies = append(inconsistencies, Inconsistency{
+					Location:    fmt.Sprintf("%s[%d].value", name, i),
+					Period:      total.Period,
+					Value:       value,
+					Parts:       partsName,
+					Aggregate:   rounded,
+					Aggregation: a,
+				})
+			}
+		}
+	}
+
+	check("years", s.Years, "quarters", s.Quarters)
+	check("quarters", s.Quarters, "months", s.Months)
+	return inconsistencies
+}
+
+// aggregate returns the sum or mean of the n values from the ordinal first, or false if any is missing.
+func aggregate(values map[int]*Decimal, first, n int, method AggregationMethod) (*big.Rat, bool) {
+	if n < 1 {
+		return nil, false
+	}
+	sum := new(big.Rat)
+	for o := first; o < first+n; o++ {
+		v, ok := values[o]
+		if !ok {
+			return nil, false
+		}
+		sum.Add(sum, v.Rat())
+	}
+	if method == Mean {
+		sum.Quo(sum, big.NewRat(int64(n), 1))
+	}
+	return sum, true
+}
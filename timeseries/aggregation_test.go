@@ -0,0 +1,74 @@
+package timeseries
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAggregation(t *testing.T) {
+	ts := series("GMAA", "visits", years("10", "26", "9"))
+	ts.Quarters = quarters(40, "1", "2", "3", "4", "5", "6", "7", "9", "1", "2", "3")
+	ts.Months = []TimeSeriesValue{
+		{Date: "1990 JAN", Value: "0.5"}, {Date: "1990 FEB", Value: "0.25"}, {Date: "1990 MAR", Value: "0.24"},
+		{Date: "1990 APR", Value: "1"}, {Date: "1990 MAY", Value: "1"}, {Date: "1990 JUN", Value: ""},
+	}
+	assert.Equal(t, "1990 Q1", ts.Quarters[0].Date)
+
+	// 1990 Q1 agrees with its months as their sum is rounded to the precision of the quarter, 0.99 to 1. 1990 Q2 has a
+	// blank month and 1992 a missing quarter so neither is checked.
+	inconsistencies := CheckAggregation(ts, Aggregation{Method: Sum})
+	require.Len(t, inconsistencies, 1)
+	assert.Equal(t, "years[1].value", inconsistencies[0].Location)
+	assert.Equal(t, "1991 is 26 but the sum of its quarters is 27 (exact)", inconsistencies[0].String())
+
+	ts.Months[2].Value = "0.9"
+	inconsistencies = CheckAggregation(ts, Aggregation{Method: Sum})
+	require.Len(t, inconsistencies, 2)
+	assert.Equal(t, "quarters[0].value", inconsistencies[1].Location)
+	assert.Equal(t, "1990 Q1 is 1 but the sum of its months is 2 (exact)", inconsistencies[1].String())
+
+	assert.Len(t, CheckAggregation(ts, Aggregation{Method: Sum, Tolerance: Tolerance{Mode: Absolute, Tolerance: 1}}), 0)
+
+	assert.Empty(t, CheckAggregation(series("GMAA", "visits", years("1")), Aggregation{}), "years alone have nothing to agree with")
+}
+
+func TestCheckAggregation_Mean(t *testing.T) {
+	ts := series("K8AA", "index", years("100.5", "100"))
+	ts.Quarters = quarters(40, "100", "101", "100", "101", "99", "99", "99", "99")
+
+	inconsistencies := CheckAggregation(ts, Aggregation{Method: Mean, Tolerance: Tolerance{Mode: Numeric}})
+	require.Len(t, inconsistencies, 1)
+	assert.Equal(t, "1991 is 100 but the mean of its quarters is 99 (numeric)", inconsistencies[0].String())
+}
+
+func TestAggregationRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aggregation")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "aggregation.json")
+	require.Nil(t, ioutil.WriteFile(filename, []byte(`{
+		"default": {"method": "sum", "mode": "relative", "tolerance": 0.001},
+		"rules": [{"dataset": "sppi", "method": "mean", "mode": "absolute", "tolerance": 0.1}]
+	}`), 0644))
+
+	rules, err := ReadAggregationRules(filename)
+	require.Nil(t, err)
+	assert.Equal(t, Aggregation{Method: Sum, Tolerance: Tolerance{Mode: Relative, Tolerance: 0.001}}, rules.For("ott", "", "GMAA"))
+	assert.Equal(t, Aggregation{Method: Mean, Tolerance: Tolerance{Mode: Absolute, Tolerance: 0.1}}, rules.For("sppi", "", "K8AA"))
+
+	for _, invalid := range []string{
+		`{"default": {"method": "median"}}`,
+		`{"rules": [{"method": "sum", "mode": "fuzzy"}]}`,
+		`{"rules": [{"cdid": "[", "method": "sum"}]}`,
+	} {
+		require.Nil(t, ioutil.WriteFile(filename, []byte(invalid), 0644))
+		_, err := ReadAggregationRules(filename)
+		assert.NotNil(t, err, invalid)
+	}
+}
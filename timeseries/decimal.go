@@ -0,0 +1,113 @@
+package timeseries
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Decimal is a decimal number that keeps the precision it was written with, so 1.50 has two decimal places. Its value
+// is unscaled × 10^-scale.
+type Decimal struct {
+	unscaled big.Int
+	scale    int
+}
+
+// maxExponent is the largest exponent accepted, beyond which the power of ten taken to compare values would be too slow
+// to compute.
+const maxExponent = 1000
+
+// ParseDecimal parses a decimal such as 12, -0.5, 1.50 or 1.2E-7. Exponents beyond ±1000 are rejected.
+func ParseDecimal(s string) (*Decimal, error) {
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return nil, errors.Errorf("invalid decimal %q", s)
+		}
+		if abs(e) > maxExponent {
+			return nil, errors.Errorf("exponent of decimal %q is out of range", s)
+		}
+		mantissa, exponent = s[:i], e
+	}
+
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	whole, fraction := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		whole, fraction = mantissa[:i], mantissa[i+1:]
+	}
+	if whole+fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return nil, errors.Errorf("invalid decimal %q", s)
+	}
+
+	d := &Decimal{scale: len(fraction) - exponent}
+	d.unscaled.SetString(sign+whole+fraction, 10)
+	return d, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Scale is the number of decimal places, negative if the decimal was written with an exponent such as 1E3.
+func (d *Decimal) Scale() int {
+	return d.scale
+}
+
+// Rat returns the exact value of the decimal.
+func (d *Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(&d.unscaled)
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(d.scale))), nil))
+	if d.scale > 0 {
+		return r.Quo(r, pow)
+	}
+	return r.Mul(r, pow)
+}
+
+// Cmp compares the values of two decimals, returning -1, 0 or +1, so 1.5 and 1.50 are equal.
+func (d *Decimal) Cmp(other *Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+// Float64 returns the nearest float64 to the decimal.
+func (d *Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// String returns the decimal with its decimal places, e.g. 1.50.
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(&d.unscaled).String()
+	sign := ""
+	if d.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+
+	switch {
+	case d.scale < 0:
+		return sign + digits + strings.Repeat("0", -d.scale)
+	case d.scale == 0:
+		return sign + digits
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,51 @@
+package timeseries
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for _, c := range []struct {
+		s      string
+		scale  int
+		string string
+	}{
+		{"12", 0, "12"},
+		{"-0.5", 1, "-0.5"},
+		{"1.50", 2, "1.50"},
+		{"+.25", 2, "0.25"},
+		{"1.2E-7", 8, "0.00000012"},
+		{"12E3", -3, "12000"},
+		{"1E-1000", 1000, "0." + strings.Repeat("0", 999) + "1"},
+		{"123456789012345678901234567890.1", 1, "123456789012345678901234567890.1"},
+	} {
+		d, err := ParseDecimal(c.s)
+		require.Nil(t, err, c.s)
+		assert.Equal(t, c.scale, d.Scale(), c.s)
+		assert.Equal(t, c.string, d.String(), c.s)
+	}
+
+	for _, invalid := range []string{"", "-", ".", "1.2.3", "1,000", "x", "1E", "NaN", "0x10", "1E1001", "1E-400000000"} {
+		_, err := ParseDecimal(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestDecimal_Cmp(t *testing.T) {
+	parse := func(s string) *Decimal {
+		d, err := ParseDecimal(s)
+		require.Nil(t, err)
+		return d
+	}
+	assert.Equal(t, 0, parse("1.5").Cmp(parse("1.50")))
+	assert.Equal(t, 0, parse("1500").Cmp(parse("1.5E3")))
+	assert.Equal(t, -1, parse("-2").Cmp(parse("-1.99")))
+	assert.Equal(t, 1, parse("0.1").Cmp(parse("0.09")))
+	assert.Equal(t, 0, parse("10000000000000000000001").Cmp(parse("10000000000000000000001.0")))
+	assert.Equal(t, 1, parse("10000000000000000000001").Cmp(parse("10000000000000000000000")), "no precision should be lost")
+	assert.Equal(t, 0.1, parse("0.10").Float64())
+}
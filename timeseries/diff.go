@@ -0,0 +1,422 @@
+package timeseries
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Diff is the difference between the expected and actual time series of a dataset, matching series by CDID and
+// values by date.
+type Diff struct {
+	// Added are the series only in the actual time series, Removed those only in the expected.
+	Added   []TimeSeries
+	Removed []TimeSeries
+	// Series are the differences of each series in both, in the expected order. Series without differences are left
+	// out.
+	Series []SeriesDiff
+	// Mappings are blocks of expected values found under another CDID or periodicity. The differences they explain
+	// are left out of Series.
+	Mappings []Mapping
+}
+
+// SeriesDiff is the difference between the expected and actual series with the same CDID.
+type SeriesDiff struct {
+	CDID  string
+	Title string
+	// ExpectedSeries and ActualSeries are the indexes of the expected and actual series.
+	ExpectedSeries int
+	ActualSeries   int
+	// Fields are the description fields, type and source datasets that changed.
+	Fields  []FieldChange
+	Periods []PeriodsDiff
+
+	key string
+}
+
+// PeriodsDiff is the difference between the expected and actual years, quarters or months of a series.
+type PeriodsDiff struct {
+	// Name is years, quarters or months.
+	Name    string
+	Added   []PeriodChange
+	Removed []PeriodChange
+	Values  []ValueChange
+	// Fields are the other fields of a period that changed, e.g. 1992 sourceDataset.
+	Fields []FieldChange
+	// Shifts are misalignments of the whole series. The value and field changes and added and removed periods
+	// they explain are left out.
+	Shifts []Shift
+}
+
+// FieldChange is a field with a different actual value.
+type FieldChange struct {
+	Field    string
+	Expected string
+	Actual   string
+}
+
+// Where a period was added or removed.
+const (
+	AtStart  = "start"
+	AtEnd    = "end"
+	InMiddle = "middle"
+)
+
+// PeriodChange is a period added to or removed from the start, end or middle of the periods of a series.
+type PeriodChange struct {
+	Date     string
+	Value    string
+	Position string
+}
+
+// ValueChange is a period with a different actual value. Delta and Relative are only set if both values are
+// numbers, Relative only if the expected value is not zero.
+type ValueChange struct {
+	Date     string
+	Expected string
+	Actual   string
+	Numeric  bool
+	Delta    float64
+	Relative float64
+}
+
+// String formats the change as "1992: 410 → 412 (+0.49%)".
+func (v ValueChange) String() string {
+	s := fmt.Sprintf("%s: %s → %s", v.Date, v.Expected, v.Actual)
+	switch {
+	case !v.Numeric:
+		return s
+	case v.Relative != 0:
+		return fmt.Sprintf("%s (%+.2f%%)", s, v.Relative*100)
+	}
+	return fmt.Sprintf("%s (%+g)", s, v.Delta)
+}
+
+// Compare returns the difference between the expected and actual time series. Series are matched by CDID, and by
+// order among series sharing a CDID.
+func Compare(expected, actual []TimeSeries) *Diff {
+	d := &Diff{}
+	actualByKey := keyByCDID(actual)
+	actualIndexes := indexByKey(actual)
+
+	expectedKeys := keyByCDID(expected)
+	for i, key := range seriesKeys(expected) {
+		a, ok := actualByKey[key]
+		if !ok {
+			d.Removed = append(d.Removed, expected[i])
+			continue
+		}
+		if sd := compareSeries(expectedKeys[key], a); !sd.Empty() {
+			sd.key = key
+			sd.ExpectedSeries, sd.ActualSeries = i, actualIndexes[key]
+			d.Series = append(d.Series, sd)
+		}
+	}
+
+	for i, key := range seriesKeys(actual) {
+		if _, ok := expectedKeys[key]; !ok {
+			d.Added = append(d.Added, actual[i])
+		}
+	}
+
+	d.Mappings = findMappings(expected, actual, actualIndexes)
+	d.explainMappings()
+	return d
+}
+
+// seriesKeys returns the CDID of each series, suffixed with #2, #3... for repeats of a CDID.
+func seriesKeys(series []TimeSeries) []string {
+	seen := make(map[string]int)
+	keys := make([]string, len(series))
+	for i, ts := range series {
+		cdid := ts.Description.CDID
+		seen[cdid]++
+		keys[i] = cdid
+		if n := seen[cdid]; n > 1 {
+			keys[i] = fmt.Sprintf("%s#%d", cdid, n)
+		}
+	}
+	return keys
+}
+
+func keyByCDID(series []TimeSeries) map[string]TimeSeries {
+	byKey := make(map[string]TimeSeries)
+	for i, key := range seriesKeys(series) {
+		byKey[key] = series[i]
+	}
+	return byKey
+}
+
+func indexByKey(series []TimeSeries) map[string]int {
+	indexes := make(map[string]int)
+	for i, key := range seriesKeys(series) {
+		indexes[key] = i
+	}
+	return indexes
+}
+
+// Empty returns true if the time series are the same.
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Series) == 0 && len(d.Mappings) == 0
+}
+
+func compareSeries(expected, actual TimeSeries) SeriesDiff {
+	sd := SeriesDiff{CDID: expected.Description.CDID, Title: expected.Description.Title}
+
+	e, a := expected.Description, actual.Description
+	for _, f := range []FieldChange{
+		{"description.title", e.Title, a.Title},
+		{"description.cdid", e.CDID, a.CDID},
+		{"description.unit", e.Unit, a.Unit},
+		{"description.preUnit", e.PreUnit, a.PreUnit},
+		{"description.source", e.Source, a.Source},
+		{"description.date", e.Date, a.Date},
+		{"description.number", e.Number, a.Number},
+		{"description.sampleSize", strconv.Itoa(e.SampleSize), strconv.Itoa(a.SampleSize)},
+		{"type", expected.Type, actual.Type},
+		{"sourceDatasets", strings.Join(expected.SourceDatasets, ","), strings.Join(actual.SourceDatasets, ",")},
+	} {
+		if f.Expected != f.Actual {
+			sd.Fields = append(sd.Fields, f)
+		}
+	}
+
+	for _, p := range []struct {
+		name             string
+		expected, actual []TimeSeriesValue
+	}{
+		{"years", expected.Years, actual.Years},
+		{"quarters", expected.Quarters, actual.Quarters},
+		{"months", expected.Months, actual.Months},
+	} {
+		if pd := comparePeriods(p.name, p.expected, p.actual); !pd.Empty() {
+			sd.Periods = append(sd.Periods, pd)
+		}
+	}
+	return sd
+}
+
+// Empty returns true if the series are the same.
+func (sd SeriesDiff) Empty() bool {
+	return len(sd.Fields) == 0 && len(sd.Periods) == 0
+}
+
+func comparePeriods(name string, expected, actual []TimeSeriesValue) PeriodsDiff {
+	pd := PeriodsDiff{Name: name}
+
+	actualByDate := make(map[string]TimeSeriesValue)
+	for _, v := range actual {
+		actualByDate[v.Date] = v
+	}
+	expectedByDate := make(map[string]TimeSeriesValue)
+	for _, v := range expected {
+		expectedByDate[v.Date] = v
+	}
+
+	for i, e := range expected {
+		a, ok := actualByDate[e.Date]
+		if !ok {
+			pd.Removed = append(pd.Removed, PeriodChange{Date: e.Date, Value: e.Value, Position: position(expected, i, actualByDate)})
+			continue
+		}
+
+		if e.Value != a.Value {
+			pd.Values = append(pd.Values, valueChange(e.Date, e.Value, a.Value))
+		}
+		for _, f := range []FieldChange{
+			{"year", e.Year, a.Year},
+			{"month", e.Month, a.Month},
+			{"quarter", e.Quarter, a.Quarter},
+			{"sourceDataset", e.SourceDataset, a.SourceDataset},
+		} {
+			if f.Expected != f.Actual {
+				f.Field = e.Date + " " + f.Field
+				pd.Fields = append(pd.Fields, f)
+			}
+		}
+	}
+
+	for i, a := range actual {
+		if _, ok := expectedByDate[a.Date]; !ok {
+			pd.Added = append(pd.Added, PeriodChange{Date: a.Date, Value: a.Value, Position: position(actual, i, expectedByDate)})
+		}
+	}
+
+	detectShift(&pd, expected, actual)
+	return pd
+}
+
+// position returns whether values[i], a date missing from other, comes before, after or between the dates in both.
+func position(values []TimeSeriesValue, i int, other map[string]TimeSeriesValue) string {
+	before, after := false, false
+	for j, v := range values {
+		if _, ok := other[v.Date]; !ok {
+			continue
+		}
+		if j < i {
+			before = true
+		} else {
+			after = true
+		}
+	}
+	switch {
+	case !before:
+		return AtStart
+	case !after:
+		return AtEnd
+	}
+	return InMiddle
+}
+
+func valueChange(date, expected, actual string) ValueChange {
+	v := ValueChange{Date: date, Expected: expected, Actual: actual}
+	e, errE := strconv.ParseFloat(strings.TrimSpace(expected), 64)
+	a, errA := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	if errE != nil || errA != nil {
+		return v
+	}
+	v.Numeric = true
+	v.Delta = a - e
+	if e != 0 {
+		v.Relative = v.Delta / e
+	}
+	return v
+}
+
+// Empty returns true if the periods are the same.
+func (pd PeriodsDiff) Empty() bool {
+	return len(pd.Added) == 0 && len(pd.Removed) == 0 && len(pd.Values) == 0 && len(pd.Fields) == 0 &&
+		len(pd.Shifts) == 0
+}
+
+// Summary counts the differences of the series, e.g. "2 values changed, 1 period added", after any shifts, e.g.
+// "values shifted by +1 quarter".
+func (sd SeriesDiff) Summary() string {
+	var parts []string
+	var values, added, removed, fields int
+	fields = len(sd.Fields)
+	for _, pd := range sd.Periods {
+		for _, s := range pd.Shifts {
+			parts = append(parts, s.String())
+		}
+		values += len(pd.Values)
+		added += len(pd.Added)
+		removed += len(pd.Removed)
+		fields += len(pd.Fields)
+	}
+
+	for _, c := range []struct {
+		n    int
+		noun string
+		verb string
+	}{
+		{values, "value", "changed"},
+		{added, "period", "added"},
+		{removed, "period", "removed"},
+		{fields, "field", "changed"},
+	} {
+		if c.n == 0 {
+			continue
+		}
+		noun := c.noun
+		if c.n > 1 {
+			noun += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s %s", c.n, noun, c.verb))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (d *Diff) String() string {
+	return d.Format(-1)
+}
+
+// Format writes the diff as text, listing at most maxValues changed values per series, or all of them if maxValues
+// is negative:
+//
+//	mapping error: GMAA quarters are under GMAB quarters
+//	series removed: GMAB OS visits to UK
+//	GMAA OS visits to UK: 1 value changed, 1 period removed
+//	  months removed at end: 2015 DEC
+//	  years 1992: 410 → 412 (+0.49%)
+func (d *Diff) Format(maxValues int) string {
+	var b strings.Builder
+	for _, m := range d.Mappings {
+		fmt.Fprintf(&b, "mapping error: %s\n", m)
+	}
+	for _, ts := range d.Removed {
+		fmt.Fprintf(&b, "series removed: %s %s\n", ts.Description.CDID, ts.Description.Title)
+	}
+	for _, ts := range d.Added {
+		fmt.Fprintf(&b, "series added: %s %s\n", ts.Description.CDID, ts.Description.Title)
+	}
+
+	for _, sd := range d.Series {
+		fmt.Fprintf(&b, "%s %s: %s\n", sd.CDID, sd.Title, sd.Summary())
+		for _, f := range sd.Fields {
+			fmt.Fprintf(&b, "  %s: %q → %q\n", f.Field, f.Expected, f.Actual)
+		}
+
+		shown := 0
+		for _, pd := range sd.Periods {
+			for _, s := range pd.Shifts {
+				fmt.Fprintf(&b, "  %s %s\n", pd.Name, s)
+			}
+			for _, group := range []struct {
+				verb    string
+				periods []PeriodChange
+			}{{"removed", pd.Removed}, {"added", pd.Added}} {
+				for _, pos := range []string{AtStart, InMiddle, AtEnd} {
+					var dates []string
+					for _, p := range group.periods {
+						if p.Position == pos {
+							dates = append(dates, p.Date)
+						}
+					}
+					if len(dates) > 0 {
+						fmt.Fprintf(&b, "  %s %s %s: %s\n", pd.Name, group.verb, positionText(pos), dateRange(dates))
+					}
+				}
+			}
+
+			for _, f := range pd.Fields {
+				fmt.Fprintf(&b, "  %s %s: %q → %q\n", pd.Name, f.Field, f.Expected, f.Actual)
+			}
+			for _, v := range pd.Values {
+				if maxValues >= 0 && shown >= maxValues {
+					break
+				}
+				fmt.Fprintf(&b, "  %s %s\n", pd.Name, v)
+				shown++
+			}
+		}
+
+		if total := sd.valueCount(); maxValues >= 0 && total > shown {
+			fmt.Fprintf(&b, "  ... and %d more changed values\n", total-shown)
+		}
+	}
+	return b.String()
+}
+
+func (sd SeriesDiff) valueCount() int {
+	n := 0
+	for _, pd := range sd.Periods {
+		n += len(pd.Values)
+	}
+	return n
+}
+
+func positionText(pos string) string {
+	if pos == InMiddle {
+		return "in the middle"
+	}
+	return "at " + pos
+}
+
+// dateRange lists up to three dates, or the first and last with the count between them.
+func dateRange(dates []string) string {
+	if len(dates) <= 3 {
+		return strings.Join(dates, ", ")
+	}
+	return fmt.Sprintf("%s … %s (%d periods)", dates[0], dates[len(dates)-1], len(dates))
+}
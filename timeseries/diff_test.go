@@ -0,0 +1,204 @@
+package timeseries
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func years(values ...string) []TimeSeriesValue {
+	var ts []TimeSeriesValue
+	for i, v := range values {
+		year := strconv.Itoa(1990 + i)
+		ts = append(ts, TimeSeriesValue{Date: year, Value: v, Year: year, SourceDataset: "OTT"})
+	}
+	return ts
+}
+
+func series(cdid, title string, values []TimeSeriesValue) TimeSeries {
+	return TimeSeries{Description: Description{CDID: cdid, Title: title}, Years: values, Type: "timeseries"}
+}
+
+func TestCompare_Same(t *testing.T) {
+	ts := []TimeSeries{series("GMAA", "visits", years("1", "2"))}
+	assert.True(t, Compare(ts, ts).Empty())
+}
+
+func TestCompare(t *testing.T) {
+	expected := []TimeSeries{
+		series("GMAA", "visits", years("410", "200", "300", "400")),
+		series("GMAB", "spending", years("1")),
+		series("GMAC", "nights", years("0", "x")),
+	}
+
+	actualGMAA := series("GMAA", "visits", years("412", "200", "300", "400", "500"))
+	actualGMAA.Years = actualGMAA.Years[1:]
+	actualGMAA.Years[0].Value = "250"
+	actualGMAA.Years[1].SourceDataset = "BB"
+	actualGMAC := series("GMAC", "nights", years("2", "y"))
+	actualGMAC.Description.Unit = "£m"
+	actual := []TimeSeries{actualGMAC, actualGMAA, series("GMAD", "new", nil)}
+
+	d := Compare(expected, actual)
+	require.Len(t, d.Removed, 1)
+	assert.Equal(t, "GMAB", d.Removed[0].Description.CDID)
+	require.Len(t, d.Added, 1)
+	assert.Equal(t, "GMAD", d.Added[0].Description.CDID)
+	require.Len(t, d.Series, 2)
+
+	gmaa := d.Series[0]
+	assert.Equal(t, "GMAA", gmaa.CDID)
+	assert.Equal(t, 0, gmaa.ExpectedSeries)
+	assert.Equal(t, 1, gmaa.ActualSeries)
+	require.Len(t, gmaa.Periods, 1)
+	years := gmaa.Periods[0]
+	assert.Equal(t, []PeriodChange{{Date: "1990", Value: "410", Position: AtStart}}, years.Removed)
+	assert.Equal(t, []PeriodChange{{Date: "1994", Value: "500", Position: AtEnd}}, years.Added)
+	assert.Equal(t, []ValueChange{{Date: "1991", Expected: "200", Actual: "250", Numeric: true, Delta: 50, Relative: 0.25}}, years.Values)
+	assert.Equal(t, []FieldChange{{Field: "1992 sourceDataset", Expected: "OTT", Actual: "BB"}}, years.Fields)
+	assert.Equal(t, "1 value changed, 1 period added, 1 period removed, 1 field changed", gmaa.Summary())
+
+	gmac := d.Series[1]
+	assert.Equal(t, 2, gmac.ExpectedSeries)
+	assert.Equal(t, 0, gmac.ActualSeries)
+	assert.Equal(t, []FieldChange{{Field: "description.unit", Expected: "", Actual: "£m"}}, gmac.Fields)
+	assert.Equal(t, "1990: 0 → 2 (+2)", gmac.Periods[0].Values[0].String())
+	assert.Equal(t, "1991: x → y", gmac.Periods[0].Values[1].String())
+
+	assert.Equal(t, `series removed: GMAB spending
+series added: GMAD new
+GMAA visits: 1 value changed, 1 period added, 1 period removed, 1 field changed
+  years removed at start: 1990
+  years added at end: 1994
+  years 1992 sourceDataset: "OTT" → "BB"
+  years 1991: 200 → 250 (+25.00%)
+GMAC nights: 2 values changed, 1 field changed
+  description.unit: "" → "£m"
+  years 1990: 0 → 2 (+2)
+  ... and 1 more changed values
+`, d.Format(1))
+}
+
+func TestValueChange_String(t *testing.T) {
+	assert.Equal(t, "1992: 410 → 412 (+0.49%)", valueChange("1992", "410", "412").String())
+	assert.Equal(t, "1992 Q1: 412 → 410 (-0.49%)", valueChange("1992 Q1", "412", "410").String())
+}
+
+func TestCompare_PeriodsRemovedInMiddle(t *testing.T) {
+	expected := []TimeSeries{series("GMAA", "visits", years("1", "2", "3", "4", "5", "6"))}
+	actual := []TimeSeries{series("GMAA", "visits", years("1", "2", "3", "4", "5", "6"))}
+	actual[0].Years = append(actual[0].Years[:1], actual[0].Years[5:]...)
+
+	d := Compare(expected, actual)
+	require.Len(t, d.Series, 1)
+	assert.Equal(t, "GMAA visits: 4 periods removed\n  years removed in the middle: 1991 … 1994 (4 periods)\n", d.String())
+}
+
+func quarters(start int, values ...string) []TimeSeriesValue {
+	var ts []TimeSeriesValue
+	for i, v := range values {
+		q := 8 + start + i
+		year, quarter := strconv.Itoa(1978+q/4), "Q"+strconv.Itoa(q%4+1)
+		ts = append(ts, TimeSeriesValue{Date: year + " " + quarter, Value: v, Year: year, Quarter: quarter})
+	}
+	return ts
+}
+
+func TestCompare_ValuesShifted(t *testing.T) {
+	values := []string{"1", "5", "2", "8", "3", "9", "4", "7"}
+	expected := []TimeSeries{{Description: Description{CDID: "GMAA"}, Quarters: quarters(0, values...)}}
+
+	for _, c := range []struct {
+		start int
+		shift string
+	}{
+		{1, "GMAA : values shifted by +1 quarter\n  quarters values shifted by +1 quarter\n"},
+		{-2, "GMAA : values shifted by -2 quarters\n  quarters values shifted by -2 quarters\n"},
+	} {
+		actual := []TimeSeries{{Description: Description{CDID: "GMAA"}, Quarters: quarters(c.start, values...)}}
+		d := Compare(expected, actual)
+		require.Len(t, d.Series, 1)
+		assert.Equal(t, c.shift, d.String())
+	}
+}
+
+func TestCompare_ValuesShiftedWithOtherChanges(t *testing.T) {
+	expected := []TimeSeries{{Description: Description{CDID: "GMAA"}, Quarters: quarters(0, "1", "5", "2", "8", "3")}}
+	actual := []TimeSeries{{Description: Description{CDID: "GMAA"}, Quarters: quarters(1, "1", "5", "2", "8", "3", "6")}}
+
+	d := Compare(expected, actual)
+	require.Len(t, d.Series, 1)
+	pd := d.Series[0].Periods[0]
+	assert.Equal(t, []Shift{{Periods: 1, Unit: "quarter"}}, pd.Shifts)
+	assert.Empty(t, pd.Values)
+	assert.Empty(t, pd.Removed, "the first expected quarter is the second actual quarter")
+	assert.Equal(t, []PeriodChange{{Date: "1981 Q3", Value: "6", Position: AtEnd}}, pd.Added)
+}
+
+func TestCompare_NotShifted(t *testing.T) {
+	expected := []TimeSeries{{Description: Description{CDID: "GMAA"}, Quarters: quarters(0, "1", "5", "2", "8", "3")}}
+	actual := []TimeSeries{{Description: Description{CDID: "GMAA"}, Quarters: quarters(0, "1", "6", "2", "9", "3")}}
+
+	d := Compare(expected, actual)
+	require.Len(t, d.Series, 1)
+	assert.Empty(t, d.Series[0].Periods[0].Shifts)
+	assert.Len(t, d.Series[0].Periods[0].Values, 2)
+}
+
+func TestCompare_LabelsShifted(t *testing.T) {
+	expected := []TimeSeries{{Description: Description{CDID: "GMAA"}, Quarters: quarters(0, "1", "5", "2", "8", "3")}}
+	actual := []TimeSeries{{Description: Description{CDID: "GMAA"}, Quarters: quarters(0, "1", "5", "2", "8", "3")}}
+	for i, v := range quarters(1, "1", "5", "2", "8", "3") {
+		actual[0].Quarters[i].Year, actual[0].Quarters[i].Quarter = v.Year, v.Quarter
+	}
+
+	d := Compare(expected, actual)
+	require.Len(t, d.Series, 1)
+	pd := d.Series[0].Periods[0]
+	assert.Equal(t, []Shift{{Periods: 1, Unit: "quarter", Labels: true}}, pd.Shifts)
+	assert.Empty(t, pd.Fields)
+	assert.Equal(t, "year/quarter/month labels shifted by +1 quarter", d.Series[0].Summary())
+}
+
+func TestCompare_SwappedSeries(t *testing.T) {
+	gmaa := TimeSeries{Description: Description{CDID: "GMAA"}, Years: years("1", "2", "3"), Quarters: quarters(0, "4", "5", "6", "7")}
+	gmab := TimeSeries{Description: Description{CDID: "GMAB"}, Years: years("8", "9", "10"), Quarters: quarters(0, "11", "12", "13", "14")}
+	expected := []TimeSeries{gmaa, gmab}
+
+	swapped := []TimeSeries{gmaa, gmab}
+	swapped[0].Quarters, swapped[1].Quarters = gmab.Quarters, gmaa.Quarters
+
+	d := Compare(expected, swapped)
+	require.Len(t, d.Mappings, 2)
+	assert.Equal(t, "GMAB quarters are under GMAA quarters", d.Mappings[0].String())
+	assert.Equal(t, "GMAA quarters are under GMAB quarters", d.Mappings[1].String())
+	assert.Equal(t, 0, d.Mappings[0].ActualSeries)
+	assert.Equal(t, 1, d.Mappings[1].ActualSeries)
+	assert.Empty(t, d.Series, "the swapped quarters should not be reported as changed values")
+	assert.Equal(t, "mapping error: GMAB quarters are under GMAA quarters\nmapping error: GMAA quarters are under GMAB quarters\n", d.String())
+}
+
+func TestCompare_RegroupedPeriodicity(t *testing.T) {
+	gmaa := TimeSeries{Description: Description{CDID: "GMAA"}, Years: years("1", "2", "3"), Quarters: quarters(0, "4", "5", "6", "7")}
+	actual := gmaa
+	actual.Years = nil
+	actual.Quarters = append(quarters(0, "4", "5", "6", "7"), quarters(4, "1", "2", "3")...)
+	actual.Months = years("1", "2", "3")
+
+	d := Compare([]TimeSeries{gmaa}, []TimeSeries{actual})
+	require.Len(t, d.Mappings, 1)
+	assert.Equal(t, Mapping{ExpectedCDID: "GMAA", ExpectedPeriods: "years", ActualCDID: "GMAA", ActualPeriods: "months", actualKey: "GMAA"}, d.Mappings[0])
+	require.Len(t, d.Series, 1, "the quarters and missing years are still differences")
+}
+
+func TestCompare_AmbiguousValuesAreNotMappings(t *testing.T) {
+	zeros := years("0", "0", "0")
+	expected := []TimeSeries{series("GMAA", "a", zeros), series("GMAB", "b", zeros), series("GMAC", "c", years("1", "2", "3"))}
+	actual := []TimeSeries{series("GMAA", "a", zeros), series("GMAB", "b", zeros), series("GMAC", "c", zeros)}
+
+	d := Compare(expected, actual)
+	assert.Empty(t, d.Mappings)
+	assert.Len(t, d.Series, 1)
+}
@@ -0,0 +1,172 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FieldAction is what a FieldRule does to the fields it matches.
+type FieldAction string
+
+const (
+	// Ignore fields, they are not compared.
+	Ignore FieldAction = "ignore"
+	// Normalise fields by replacing every match of the pattern before comparing them.
+	Normalise FieldAction = "normalise"
+	// Format only checks the actual field matches the pattern, it is not compared with the expected field.
+	Format FieldAction = "format"
+)
+
+// FieldRule overrides the comparison of the fields matching Path in the named dataset, or in every dataset if Dataset
+// is empty. Path is a pattern (see path.Match) of the JSON names of a field, e.g. description.date or
+// *.sourceDataset for the values of every periodicity.
+type FieldRule struct {
+	Dataset string      `json:"dataset,omitempty"`
+	Path    string      `json:"path"`
+	Action  FieldAction `json:"action"`
+	Pattern string      `json:"pattern,omitempty"`
+	Replace string      `json:"replace,omitempty"`
+
+	re *regexp.Regexp
+}
+
+func (r FieldRule) matches(dataset, field string) bool {
+	if r.Dataset != "" && r.Dataset != dataset {
+		return false
+	}
+	ok, _ := path.Match(r.Path, field)
+	return ok
+}
+
+// FieldRules are applied in order to the description and values of each series before they are compared.
+type FieldRules struct {
+	Rules []FieldRule `json:"rules"`
+}
+
+// FormatError is an actual field that does not match the format of a rule.
+type FormatError struct {
+	// Location of the field in its series, e.g. months[3].sourceDataset.
+	Location string
+	Field    string
+	Value    string
+	Pattern  string
+}
+
+func (e FormatError) Error() string {
+	return fmt.Sprintf("%s %q does not match the format %s", e.Location, e.Value, e.Pattern)
+}
+
+// Normalise returns a copy of the series of the dataset with the ignored and format-only fields cleared and the
+// normalised fields rewritten, so only what the rules leave is compared.
+func (r *FieldRules) Normalise(dataset string, ts TimeSeries) TimeSeries {
+	if r == nil {
+		return ts
+	}
+	ts.Years = append([]TimeSeriesValue(nil), ts.Years...)
+	ts.Quarters = append([]TimeSeriesValue(nil), ts.Quarters...)
+	ts.Months = append([]TimeSeriesValue(nil), ts.Months...)
+
+	eachField(&ts, func(location, field string, v reflect.Value) {
+		for _, rule := range r.Rules {
+			if !rule.matches(dataset, field) {
+				continue
+			}
+			switch {
+			case rule.Action == Ignore || rule.Action == Format:
+				v.Set(reflect.Zero(v.Type()))
+			case rule.Action == Normalise && v.Kind() == reflect.String:
+				v.SetString(rule.re.ReplaceAllString(v.String(), rule.Replace))
+			}
+		}
+	})
+	return ts
+}
+
+// CheckFormats returns the fields of the series of the dataset that do not match the format of their rules.
+func (r *FieldRules) CheckFormats(dataset string, ts TimeSeries) []FormatError {
+	if r == nil {
+		return nil
+	}
+
+	var formatErrors []FormatError
+	eachField(&ts, func(location, field string, v reflect.Value) {
+		for _, rule := range r.Rules {
+			if rule.Action != Format || !rule.matches(dataset, field) {
+				continue
+			}
+			value := fmt.Sprint(v.Interface())
+			if !rule.re.MatchString(value) {
+				formatErrors = append(formatErrors, FormatError{Location: location, Field: field, Value: value, Pattern: rule.Pattern})
+			}
+		}
+	})
+	return formatErrors
+}
+
+// eachField calls fn with every field of the description and values of a series, its location in the series and its
+// path of JSON names, e.g. months[3].sourceDataset and months.sourceDataset.
+func eachField(ts *TimeSeries, fn func(location, field string, v reflect.Value)) {
+	structFields(reflect.ValueOf(&ts.Description).Elem(), func(name string, v reflect.Value) {
+		fn("description."+name, "description."+name, v)
+	})
+	for _, b := range []struct {
+		name   string
+		values []TimeSeriesValue
+	}{{"years", ts.Years}, {"quarters", ts.Quarters}, {"months", ts.Months}} {
+		for i := range b.values {
+			structFields(reflect.ValueOf(&b.values[i]).Elem(), func(name string, v reflect.Value) {
+				fn(fmt.Sprintf("%s[%d].%s", b.name, i, name), b.name+"."+name, v)
+			})
+		}
+	}
+}
+
+func structFields(v reflect.Value, fn func(name string, v reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		fn(strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0], v.Field(i))
+	}
+}
+
+// ReadFieldRules reads field rules from a JSON file such as:
+//
+//	{
+//	  "rules": [
+//	    {"path": "description.date", "action": "format", "pattern": "^\\d{2}-\\d{2}-\\d{4}$"},
+//	    {"path": "description.title", "action": "normalise", "pattern": "\\s+", "replace": " "},
+//	    {"dataset": "ott", "path": "*.sourceDataset", "action": "ignore"}
+//	  ]
+//	}
+func ReadFieldRules(filename string) (*FieldRules, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules FieldRules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, errors.Wrapf(err, "error reading field rules %s", filename)
+	}
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		if _, err := path.Match(rule.Path, ""); err != nil || rule.Path == "" {
+			return nil, errors.Errorf("%s: rules[%d]: invalid path %q", filename, i, rule.Path)
+		}
+		switch rule.Action {
+		case Ignore:
+		case Normalise, Format:
+			if rule.re, err = regexp.Compile(rule.Pattern); err != nil {
+				return nil, errors.Wrapf(err, "%s: rules[%d]: pattern", filename, i)
+			}
+		default:
+			return nil, errors.Errorf("%s: rules[%d]: unknown action %q", filename, i, rule.Action)
+		}
+	}
+	return &rules, nil
+}
@@ -0,0 +1,83 @@
+package timeseries
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFieldRules() *FieldRules {
+	return &FieldRules{Rules: []FieldRule{
+		{Path: "description.date", Action: Format, Pattern: `^\d{2}-\d{2}-\d{4}$`, re: regexp.MustCompile(`^\d{2}-\d{2}-\d{4}$`)},
+		{Path: "description.title", Action: Normalise, Pattern: `\s+`, Replace: " ", re: regexp.MustCompile(`\s+`)},
+		{Dataset: "ott", Path: "*.sourceDataset", Action: Ignore},
+	}}
+}
+
+func TestFieldRules_Normalise(t *testing.T) {
+	ts := series("GMAA", "OS  visits\tto UK", years("1", "2"))
+	ts.Description.Date = "01-02-2017"
+
+	var none *FieldRules
+	assert.Equal(t, ts, none.Normalise("ott", ts))
+
+	normalised := testFieldRules().Normalise("ott", ts)
+	assert.Equal(t, "OS visits to UK", normalised.Description.Title)
+	assert.Empty(t, normalised.Description.Date, "format only fields should not be compared")
+	assert.Empty(t, normalised.Years[0].SourceDataset)
+	assert.Equal(t, "1", normalised.Years[0].Value)
+	assert.Equal(t, "OTT", ts.Years[0].SourceDataset, "the series should not be modified")
+
+	normalised = testFieldRules().Normalise("berd", ts)
+	assert.Equal(t, "OTT", normalised.Years[1].SourceDataset, "rules of other datasets should not apply")
+}
+
+func TestFieldRules_CheckFormats(t *testing.T) {
+	ts := series("GMAA", "visits", years("1"))
+	ts.Description.Date = "01-02-2017"
+	assert.Empty(t, testFieldRules().CheckFormats("ott", ts))
+
+	ts.Description.Date = "2017-02-01"
+	errs := testFieldRules().CheckFormats("ott", ts)
+	require.Len(t, errs, 1)
+	assert.Equal(t, FormatError{Location: "description.date", Field: "description.date", Value: "2017-02-01", Pattern: `^\d{2}-\d{2}-\d{4}$`}, errs[0])
+
+	rules := &FieldRules{Rules: []FieldRule{{Path: "months.sourceDataset", Action: Format, Pattern: "^[A-Z]+$", re: regexp.MustCompile("^[A-Z]+$")}}}
+	ts.Months = []TimeSeriesValue{{SourceDataset: "OTT"}, {SourceDataset: "ott"}}
+	errs = rules.CheckFormats("ott", ts)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "months[1].sourceDataset", errs[0].Location)
+}
+
+func TestReadFieldRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fields")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(content string) string {
+		filename := filepath.Join(dir, "fields.json")
+		require.Nil(t, ioutil.WriteFile(filename, []byte(content), 0644))
+		return filename
+	}
+
+	rules, err := ReadFieldRules(write(`{"rules": [{"path": "description.title", "action": "normalise", "pattern": "\\s+", "replace": " "}]}`))
+	require.Nil(t, err)
+	require.Len(t, rules.Rules, 1)
+	assert.Equal(t, "a b", rules.Rules[0].re.ReplaceAllString("a  b", rules.Rules[0].Replace))
+
+	for _, invalid := range []string{
+		`{"rules": [{"path": "description.date", "action": "drop"}]}`,
+		`{"rules": [{"path": "description.date", "action": "format", "pattern": "("}]}`,
+		`{"rules": [{"path": "[description", "action": "ignore"}]}`,
+		`{"rules": [{"action": "ignore"}]}`,
+		`{"rules": `,
+	} {
+		_, err := ReadFieldRules(write(invalid))
+		assert.NotNil(t, err, invalid)
+	}
+}
@@ -0,0 +1,115 @@
+package timeseries
+
+import (
+	"fmt"
+)
+
+// Violation is a field of a series breaking an invariant that holds for every response, golden or not.
+type Violation struct {
+	// Location of the field in its series, e.g. months[3].month.
+	Location string
+	Message  string
+}
+
+func (v Violation) String() string {
+	return v.Location + ": " + v.Message
+}
+
+// CheckInvariants returns the fields of the series that cannot be parsed or break an invariant:
+//
+//   - the type and CDID are not empty
+//   - the year, quarter and month of every value agree with its date
+//   - the years, quarters and months are in chronological order with no duplicates or gaps
+//   - the sourceDataset of every value is one of the sourceDatasets of the series
+func CheckInvariants(ts TimeSeries) []Violation {
+	var violations []Violation
+	reported := make(map[string]bool)
+	violate := func(location, format string, args ...interface{}) {
+		// A field that cannot be parsed is only reported once, not again for breaking the invariants.
+		if reported[location] {
+			return
+		}
+		reported[location] = true
+		violations = append(violations, Violation{Location: location, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ts.Type == "" {
+		violate("type", "is empty")
+	}
+	if ts.Description.CDID == "" {
+		violate("description.cdid", "is empty")
+	}
+
+	s, err := ts.Parse()
+	if errs, ok := err.(ParseErrors); ok {
+		for _, e := range errs {
+			violate(e.Location, "%s", e.Err)
+		}
+	}
+
+	sourceDatasets := make(map[string]bool)
+	for _, sd := range ts.SourceDatasets {
+		sourceDatasets[sd] = true
+	}
+
+	for _, b := range []struct {
+		name   string
+		values []TimeSeriesValue
+		parsed []Observation
+	}{{"years", ts.Years, s.Years}, {"quarters", ts.Quarters, s.Quarters}, {"months", ts.Months, s.Months}} {
+		var previous *Period
+		for i, o := range b.parsed {
+			location := fmt.Sprintf("%s[%d]", b.name, i)
+			if !sourceDatasets[o.SourceDataset] {
+				violate(location+".sourceDataset", "%q is not one of the sourceDatasets %q", o.SourceDataset, ts.SourceDatasets)
+			}
+
+			// The date could not be parsed, which is already a violation.
+			if o.Period.Periodicity == 0 {
+				continue
+			}
+			checkLabels(violate, location, b.values[i], o)
+
+			if previous != nil {
+				switch gap := o.Period.Ordinal() - previous.Ordinal(); {
+				case gap == 0:
+					violate(location+".date", "%s is a duplicate", o.Period)
+				case gap < 0:
+					violate(location+".date", "%s is before the previous %s", o.Period, previous)
+				case gap == 2:
+					violate(location+".date", "%s follows %s, missing %s", o.Period, previous, previous.Add(1))
+				case gap > 2:
+					violate(location+".date", "%s follows %s, missing %s to %s", o.Period, previous, previous.Add(1), o.Period.Add(-1))
+				}
+			}
+			period := o.Period
+			previous = &period
+		}
+	}
+	return violations
+}
+
+// checkLabels checks the year, quarter and month labels of a value agree with its date. Labels that do not apply to
+// the periodicity, such as the month of a year, must be blank, except the quarter of a month which may be given.
+func checkLabels(violate func(location, format string, args ...interface{}), location string, v TimeSeriesValue, o Observation) {
+	p := o.Period
+	if v.Year == "" || o.Year != p.Year {
+		violate(location+".year", "%q does not agree with the date %s", v.Year, p)
+	}
+
+	switch {
+	case p.Periodicity == Quarterly && o.Quarter != p.Quarter():
+		violate(location+".quarter", "%q does not agree with the date %s", v.Quarter, p)
+	case p.Periodicity == Monthly && v.Quarter != "" && o.Quarter != p.Quarter():
+		violate(location+".quarter", "%q does not agree with the date %s", v.Quarter, p)
+	case p.Periodicity == Annual && v.Quarter != "":
+		violate(location+".quarter", "%q should be blank for the year %s", v.Quarter, p)
+	}
+
+	switch {
+	case p.Periodicity == Monthly && int(o.Month) != p.Index:
+		violate(location+".month", "%q does not agree with the date %s", v.Month, p)
+	case p.Periodicity != Monthly && v.Month != "":
+		violate(location+".month", "%q should be blank for the %s %s", v.Month, p.Periodicity, p)
+	}
+}
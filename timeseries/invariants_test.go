@@ -0,0 +1,62 @@
+package timeseries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckInvariants(t *testing.T) {
+	ts := series("GMAA", "visits", years("1", "2", "3"))
+	ts.SourceDatasets = []string{"OTT"}
+	ts.Quarters = quarters(0, "1", "2", "3", "4", "5")
+	for i := range ts.Quarters {
+		ts.Quarters[i].SourceDataset = "OTT"
+	}
+	ts.Months = []TimeSeriesValue{
+		{Date: "1980 JAN", Value: "1", Year: "1980", Month: "January", SourceDataset: "OTT"},
+		{Date: "1980 FEB", Value: "2", Year: "1980", Month: "February", SourceDataset: "OTT"},
+	}
+	assert.Empty(t, CheckInvariants(ts))
+}
+
+func TestCheckInvariants_Violations(t *testing.T) {
+	ts := series("", "visits", years("1", "2", "3", "4", "5"))
+	ts.Type = ""
+	ts.SourceDatasets = []string{"OTT"}
+	ts.Years[1].Date, ts.Years[1].Year = "1990", "1990"
+	ts.Years[3].Date, ts.Years[3].Year = "1995", "1995"
+	ts.Years[4].Year = "1996"
+	ts.Years[4].SourceDataset = "BB"
+	ts.Quarters = quarters(0, "1", "2", "3")
+	ts.Quarters[1].Quarter = "Q3"
+	ts.Quarters[2].Year = "nineteen"
+	for i := range ts.Quarters {
+		ts.Quarters[i].SourceDataset = "OTT"
+	}
+	ts.Months = []TimeSeriesValue{
+		{Date: "1980 MAR", Value: "1", Year: "1980", Month: "March", Quarter: "Q1", SourceDataset: "OTT"},
+		{Date: "1980 FEB", Value: "2", Year: "1980", Month: "March", SourceDataset: "OTT"},
+		{Date: "1980 JUN", Value: "3", Year: "1980", Month: "June", SourceDataset: "OTT"},
+	}
+
+	var violations []string
+	for _, v := range CheckInvariants(ts) {
+		violations = append(violations, v.String())
+	}
+	assert.Equal(t, []string{
+		"type: is empty",
+		"description.cdid: is empty",
+		`quarters[2].year: invalid year "nineteen"`,
+		"years[1].date: 1990 is a duplicate",
+		"years[2].date: 1992 follows 1990, missing 1991",
+		"years[3].date: 1995 follows 1992, missing 1993 to 1994",
+		`years[4].sourceDataset: "BB" is not one of the sourceDatasets ["OTT"]`,
+		`years[4].year: "1996" does not agree with the date 1994`,
+		"years[4].date: 1994 is before the previous 1995",
+		`quarters[1].quarter: "Q3" does not agree with the date 1980 Q2`,
+		`months[1].month: "March" does not agree with the date 1980 FEB`,
+		"months[1].date: 1980 FEB is before the previous 1980 MAR",
+		"months[2].date: 1980 JUN follows 1980 FEB, missing 1980 MAR to 1980 MAY",
+	}, violations)
+}
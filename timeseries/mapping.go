@@ -0,0 +1,123 @@
+package timeseries
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mapping is an expected block of values, the years, quarters or months of a series, found in full under another
+// CDID or periodicity of the actual series.
+type Mapping struct {
+	ExpectedCDID    string
+	ExpectedPeriods string
+	ActualCDID      string
+	ActualPeriods   string
+	// ActualSeries is the index of the actual series.
+	ActualSeries int
+
+	actualKey string
+}
+
+func (m Mapping) String() string {
+	return fmt.Sprintf("%s %s are under %s %s", m.ExpectedCDID, m.ExpectedPeriods, m.ActualCDID, m.ActualPeriods)
+}
+
+// block is the years, quarters or months of a series.
+type block struct {
+	key     string
+	cdid    string
+	periods string
+	values  []TimeSeriesValue
+}
+
+func blocks(series []TimeSeries) []block {
+	var all []block
+	for i, key := range seriesKeys(series) {
+		ts := series[i]
+		for _, b := range []block{
+			{key, ts.Description.CDID, "years", ts.Years},
+			{key, ts.Description.CDID, "quarters", ts.Quarters},
+			{key, ts.Description.CDID, "months", ts.Months},
+		} {
+			if len(b.values) > 0 {
+				all = append(all, b)
+			}
+		}
+	}
+	return all
+}
+
+// fingerprint identifies a block by its sequence of values, ignoring dates so a block moved to another periodicity is
+// still found.
+func fingerprint(values []TimeSeriesValue) string {
+	v := make([]string, len(values))
+	for i, value := range values {
+		v[i] = value.Value
+	}
+	return strings.Join(v, "\x00")
+}
+
+// findMappings looks up the values of every actual block among the expected blocks. An actual block whose values are
+// those of an expected block of another CDID or periodicity, rather than its own, is a mapping. Short blocks, and
+// values shared by several expected blocks, are too ambiguous to say where they came from.
+func findMappings(expected, actual []TimeSeries, actualIndexes map[string]int) []Mapping {
+	byFingerprint := make(map[string]block)
+	ambiguous := make(map[string]bool)
+	own := make(map[string]string)
+	for _, b := range blocks(expected) {
+		f := fingerprint(b.values)
+		own[b.key+" "+b.periods] = f
+		if len(b.values) < minShiftOverlap {
+			continue
+		}
+		if _, ok := byFingerprint[f]; ok {
+			ambiguous[f] = true
+		}
+		byFingerprint[f] = b
+	}
+
+	var mappings []Mapping
+	for _, b := range blocks(actual) {
+		f := fingerprint(b.values)
+		e, ok := byFingerprint[f]
+		if !ok || ambiguous[f] || own[b.key+" "+b.periods] == f {
+			continue
+		}
+		if e.key == b.key && e.periods == b.periods {
+			continue
+		}
+		mappings = append(mappings, Mapping{
+			ExpectedCDID:    e.cdid,
+			ExpectedPeriods: e.periods,
+			ActualCDID:      b.cdid,
+			ActualPeriods:   b.periods,
+			ActualSeries:    actualIndexes[b.key],
+			actualKey:       b.key,
+		})
+	}
+	return mappings
+}
+
+// explainMappings removes the differences of the actual blocks the mappings account for, and any series left with no
+// differences.
+func (d *Diff) explainMappings() {
+	explained := make(map[string]bool)
+	for _, m := range d.Mappings {
+		explained[m.actualKey+" "+m.ActualPeriods] = true
+	}
+
+	var series []SeriesDiff
+	for _, sd := range d.Series {
+		var periods []PeriodsDiff
+		for _, pd := range sd.Periods {
+			if !explained[sd.key+" "+pd.Name] {
+				periods = append(periods, pd)
+			}
+		}
+		sd.Periods = periods
+		if !sd.Empty() {
+			series = append(series, sd)
+		}
+	}
+	d.Series = series
+}
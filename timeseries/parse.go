@@ -0,0 +1,142 @@
+package timeseries
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Observation is a TimeSeriesValue parsed.
+type Observation struct {
+	Period Period
+	// Value is nil if the value is blank.
+	Value *Decimal
+	// Year, Quarter and Month are the labels of the value, 0 if blank.
+	Year          int
+	Quarter       int
+	Month         time.Month
+	SourceDataset string
+}
+
+// Series is a TimeSeries parsed.
+type Series struct {
+	Description Description
+	// Date is the description date, zero if blank.
+	Date           time.Time
+	Years          []Observation
+	Quarters       []Observation
+	Months         []Observation
+	SourceDatasets []string
+	Type           string
+}
+
+// ParseError is a field of a series that could not be parsed.
+type ParseError struct {
+	// Location of the field in its series, e.g. months[3].value.
+	Location string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	return e.Location + ": " + e.Err.Error()
+}
+
+// ParseErrors are every field of a series that could not be parsed.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// descriptionDateLayouts are the layouts a description date is parsed with, in order.
+var descriptionDateLayouts = []string{"02-01-2006", "2006-01-02", "02/01/2006", time.RFC3339}
+
+// Parse parses the description date and the periods, values and labels of the series, returning ParseErrors for
+// every field that could not be parsed.
+func (ts TimeSeries) Parse() (*Series, error) {
+	var errs ParseErrors
+	fail := func(location string, err error) {
+		errs = append(errs, &ParseError{Location: location, Err: err})
+	}
+
+	s := &Series{Description: ts.Description, SourceDatasets: ts.SourceDatasets, Type: ts.Type}
+	if ts.Description.Date != "" {
+		date, err := parseDescriptionDate(ts.Description.Date)
+		if err != nil {
+			fail("description.date", err)
+		}
+		s.Date = date
+	}
+
+	for _, b := range []struct {
+		name   string
+		values []TimeSeriesValue
+		parsed *[]Observation
+	}{{"years", ts.Years, &s.Years}, {"quarters", ts.Quarters, &s.Quarters}, {"months", ts.Months, &s.Months}} {
+		p, _ := PeriodicityOf(b.name)
+		for i, v := range b.values {
+			o, fieldErrs := v.parse(p)
+			for _, e := range fieldErrs {
+				fail(fmt.Sprintf("%s[%d].%s", b.name, i, e.field), e.err)
+			}
+			*b.parsed = append(*b.parsed, o)
+		}
+	}
+
+	if len(errs) > 0 {
+		return s, errs
+	}
+	return s, nil
+}
+
+func parseDescriptionDate(date string) (time.Time, error) {
+	for _, layout := range descriptionDateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid date %q", date)
+}
+
+// fieldError is a field of a value that could not be parsed.
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (v TimeSeriesValue) parse(p Periodicity) (Observation, []fieldError) {
+	o := Observation{SourceDataset: v.SourceDataset}
+	var errs []fieldError
+	var err error
+	if o.Period, err = ParsePeriod(p, v.Date); err != nil {
+		errs = append(errs, fieldError{"date", err})
+	}
+	if v.Value != "" {
+		if o.Value, err = ParseDecimal(v.Value); err != nil {
+			errs = append(errs, fieldError{"value", err})
+		}
+	}
+	if v.Year != "" {
+		if o.Year, err = strconv.Atoi(v.Year); err != nil {
+			errs = append(errs, fieldError{"year", errors.Errorf("invalid year %q", v.Year)})
+		}
+	}
+	if v.Quarter != "" {
+		if o.Quarter = parseQuarter(v.Quarter); o.Quarter == 0 {
+			errs = append(errs, fieldError{"quarter", errors.Errorf("invalid quarter %q", v.Quarter)})
+		}
+	}
+	if v.Month != "" {
+		if o.Month = parseMonth(v.Month); o.Month == 0 {
+			errs = append(errs, fieldError{"month", errors.Errorf("invalid month %q", v.Month)})
+		}
+	}
+	return o, errs
+}
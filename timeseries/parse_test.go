@@ -0,0 +1,65 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeSeries_Parse(t *testing.T) {
+	ts := series("GMAA", "visits", years("12419", "", "1.50"))
+	ts.Description.Date = "01-02-2017"
+	ts.Quarters = []TimeSeriesValue{{Date: "1980 Q1", Value: "2081", Year: "1980", Quarter: "Q1"}}
+	ts.Months = []TimeSeriesValue{{Date: "1980 JAN", Value: "739", Year: "1980", Month: "January"}}
+
+	s, err := ts.Parse()
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2017, time.February, 1, 0, 0, 0, 0, time.UTC), s.Date)
+	require.Len(t, s.Years, 3)
+	assert.Equal(t, Period{Annual, 1990, 0}, s.Years[0].Period)
+	assert.Equal(t, "12419", s.Years[0].Value.String())
+	assert.Nil(t, s.Years[1].Value, "blank values should be nil")
+	assert.Equal(t, 2, s.Years[2].Value.Scale())
+	assert.Equal(t, 1992, s.Years[2].Year)
+	assert.Equal(t, 1, s.Quarters[0].Quarter)
+	assert.Equal(t, time.January, s.Months[0].Month)
+	assert.Equal(t, "OTT", s.Years[0].SourceDataset)
+}
+
+func TestTimeSeries_ParseErrors(t *testing.T) {
+	ts := series("GMAA", "visits", years("1", "1,000", "3"))
+	ts.Description.Date = "yesterday"
+	ts.Years[2].Year = "MCMXCII"
+	ts.Months = []TimeSeriesValue{
+		{Date: "1980 JAN", Value: "739", Year: "1980", Month: "January"},
+		{Date: "1980 Q1", Value: "x", Year: "1980", Month: "Febuary"},
+	}
+
+	_, err := ts.Parse()
+	require.NotNil(t, err)
+	errs, ok := err.(ParseErrors)
+	require.True(t, ok, "the error should be ParseErrors")
+
+	var locations []string
+	for _, e := range errs {
+		locations = append(locations, e.Location)
+	}
+	assert.Equal(t, []string{
+		"description.date",
+		"years[1].value",
+		"years[2].year",
+		"months[1].date",
+		"months[1].value",
+		"months[1].month",
+	}, locations)
+	assert.Equal(t, `years[1].value: invalid decimal "1,000"`, errs[1].Error())
+	assert.Contains(t, err.Error(), `months[1].month: invalid month "Febuary"`)
+}
+
+func TestTimeSeries_ParseExponentOutOfRange(t *testing.T) {
+	_, err := series("GMAA", "visits", years("1E-400000000")).Parse()
+	require.NotNil(t, err)
+	assert.Equal(t, `years[0].value: exponent of decimal "1E-400000000" is out of range`, err.Error())
+}
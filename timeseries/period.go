@@ -0,0 +1,171 @@
+package timeseries
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var monthNames = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+// Periodicity is how often a series has a value: every year, quarter or month.
+type Periodicity int
+
+const (
+	Annual Periodicity = iota + 1
+	Quarterly
+	Monthly
+)
+
+// PeriodicityOf returns the periodicity of the values of a series in the years, quarters or months field.
+func PeriodicityOf(field string) (Periodicity, bool) {
+	switch field {
+	case "years":
+		return Annual, true
+	case "quarters":
+		return Quarterly, true
+	case "months":
+		return Monthly, true
+	}
+	return 0, false
+}
+
+// PerYear is the number of periods in a year.
+func (p Periodicity) PerYear() int {
+	switch p {
+	case Quarterly:
+		return 4
+	case Monthly:
+		return 12
+	}
+	return 1
+}
+
+// String returns the unit of the periodicity: year, quarter or month.
+func (p Periodicity) String() string {
+	switch p {
+	case Annual:
+		return "year"
+	case Quarterly:
+		return "quarter"
+	case Monthly:
+		return "month"
+	}
+	return "Periodicity(" + strconv.Itoa(int(p)) + ")"
+}
+
+// Period is a year, quarter or month. Index is the quarter (1-4) or month (1-12) of the year, 0 for a year.
+type Period struct {
+	Periodicity Periodicity
+	Year        int
+	Index       int
+}
+
+// ParsePeriod parses the date of a value of the periodicity: 1980, 1980 Q1 or 1980 JAN.
+func ParsePeriod(p Periodicity, date string) (Period, error) {
+	fields := strings.Fields(date)
+	want := 2
+	if p == Annual {
+		want = 1
+	}
+	if len(fields) != want {
+		return Period{}, errors.Errorf("invalid %s %q", p, date)
+	}
+
+	year, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Period{}, errors.Errorf("invalid year in %s %q", p, date)
+	}
+	period := Period{Periodicity: p, Year: year}
+	switch p {
+	case Quarterly:
+		period.Index = parseQuarter(fields[1])
+	case Monthly:
+		for i, m := range monthNames {
+			if strings.ToUpper(fields[1]) == m {
+				period.Index = i + 1
+			}
+		}
+	}
+	if p != Annual && period.Index == 0 {
+		return Period{}, errors.Errorf("invalid %s in %q", p, date)
+	}
+	return period, nil
+}
+
+// parseQuarter returns the quarter of Q1 to Q4, or 0.
+func parseQuarter(s string) int {
+	s = strings.ToUpper(s)
+	if len(s) != 2 || s[0] != 'Q' || s[1] < '1' || s[1] > '4' {
+		return 0
+	}
+	return int(s[1] - '0')
+}
+
+// parseMonth returns the month of a month name such as January, or its first three letters, or 0.
+func parseMonth(s string) time.Month {
+	s = strings.ToUpper(s)
+	if len(s) < 3 {
+		return 0
+	}
+	for i, m := range monthNames {
+		if strings.HasPrefix(s, m) && strings.HasPrefix(strings.ToUpper(time.Month(i+1).String()), s) {
+			return time.Month(i + 1)
+		}
+	}
+	return 0
+}
+
+// Ordinal numbers the period as a count of periods since year 0, so consecutive periods differ by one.
+func (p Period) Ordinal() int {
+	if p.Periodicity == Annual {
+		return p.Year
+	}
+	return p.Year*p.Periodicity.PerYear() + p.Index - 1
+}
+
+// Add returns the period n periods later, or earlier if n is negative.
+func (p Period) Add(n int) Period {
+	if p.Periodicity == Annual {
+		return Period{Periodicity: Annual, Year: p.Year + n}
+	}
+	o := p.Ordinal() + n
+	perYear := p.Periodicity.PerYear()
+	year := o / perYear
+	if o < 0 && o%perYear != 0 {
+		year--
+	}
+	return Period{Periodicity: p.Periodicity, Year: year, Index: o - year*perYear + 1}
+}
+
+// Before returns true if the period is earlier than the other period of the same periodicity.
+func (p Period) Before(other Period) bool {
+	return p.Ordinal() < other.Ordinal()
+}
+
+// Quarter returns the quarter of a quarter or month, or 0 for a year.
+func (p Period) Quarter() int {
+	switch p.Periodicity {
+	case Quarterly:
+		return p.Index
+	case Monthly:
+		return (p.Index-1)/3 + 1
+	}
+	return 0
+}
+
+// String returns the period as brian writes dates: 1980, 1980 Q1 or 1980 JAN.
+func (p Period) String() string {
+	year := strconv.Itoa(p.Year)
+	switch p.Periodicity {
+	case Quarterly:
+		return year + " Q" + strconv.Itoa(p.Index)
+	case Monthly:
+		if p.Index >= 1 && p.Index <= 12 {
+			return year + " " + monthNames[p.Index-1]
+		}
+	}
+	return year
+}
@@ -0,0 +1,57 @@
+package timeseries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeriod(t *testing.T) {
+	for _, c := range []struct {
+		periodicity Periodicity
+		date        string
+		period      Period
+	}{
+		{Annual, "1980", Period{Annual, 1980, 0}},
+		{Quarterly, "1980 Q3", Period{Quarterly, 1980, 3}},
+		{Monthly, "1980 DEC", Period{Monthly, 1980, 12}},
+		{Monthly, "1980 jan", Period{Monthly, 1980, 1}},
+	} {
+		p, err := ParsePeriod(c.periodicity, c.date)
+		require.Nil(t, err, c.date)
+		assert.Equal(t, c.period, p, c.date)
+	}
+
+	for _, c := range []struct {
+		periodicity Periodicity
+		date        string
+		err         string
+	}{
+		{Annual, "1980 Q1", `invalid year "1980 Q1"`},
+		{Quarterly, "1980", `invalid quarter "1980"`},
+		{Quarterly, "1980 Q5", `invalid quarter in "1980 Q5"`},
+		{Monthly, "1980 Q1", `invalid month in "1980 Q1"`},
+		{Monthly, "80s JAN", `invalid year in month "80s JAN"`},
+	} {
+		_, err := ParsePeriod(c.periodicity, c.date)
+		require.NotNil(t, err, c.date)
+		assert.Equal(t, c.err, err.Error())
+	}
+}
+
+func TestPeriod_Ordering(t *testing.T) {
+	dec := Period{Monthly, 1980, 12}
+	assert.Equal(t, Period{Monthly, 1981, 1}, dec.Add(1))
+	assert.Equal(t, Period{Monthly, 1979, 12}, dec.Add(-12))
+	assert.Equal(t, dec.Ordinal()+1, dec.Add(1).Ordinal())
+	assert.True(t, dec.Before(dec.Add(1)))
+	assert.False(t, dec.Before(dec))
+	assert.Equal(t, Period{Quarterly, -1, 4}, Period{Quarterly, 0, 1}.Add(-1))
+	assert.Equal(t, Period{Annual, 1990, 0}, Period{Annual, 1980, 0}.Add(10))
+
+	assert.Equal(t, 4, dec.Quarter())
+	assert.Equal(t, "1980 DEC", dec.String())
+	assert.Equal(t, "1980 Q2", Period{Quarterly, 1980, 2}.String())
+	assert.Equal(t, "1980", Period{Annual, 1980, 0}.String())
+}
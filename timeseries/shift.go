@@ -0,0 +1,214 @@
+package timeseries
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Shift is a misalignment of a series: either every actual value is the expected value of the period Periods
+// earlier (or later if negative), or, if Labels is true, the dates match but the year, quarter and month labels of
+// every actual value are those of the period Periods later.
+type Shift struct {
+	Periods int
+	// Unit is year, quarter or month.
+	Unit   string
+	Labels bool
+}
+
+// String describes the shift, e.g. "values shifted by +1 quarter".
+func (s Shift) String() string {
+	what := "values"
+	if s.Labels {
+		what = "year/quarter/month labels"
+	}
+	unit := s.Unit
+	if s.Periods != 1 && s.Periods != -1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%s shifted by %+d %s", what, s.Periods, unit)
+}
+
+var periodUnits = map[string]string{"years": "year", "quarters": "quarter", "months": "month"}
+
+// periodsPerYear of the years, quarters and months of a series.
+func periodsPerYear(name string) int {
+	p, _ := PeriodicityOf(name)
+	return p.PerYear()
+}
+
+// dateOrdinal is the ordinal of a date such as 1980, 1980 Q1 or 1980 JAN.
+func dateOrdinal(name, date string) (int, bool) {
+	p, ok := PeriodicityOf(name)
+	if !ok {
+		return 0, false
+	}
+	period, err := ParsePeriod(p, date)
+	if err != nil {
+		return 0, false
+	}
+	return period.Ordinal(), true
+}
+
+// labelOrdinal is the ordinal of the year, quarter and month fields of a value.
+func labelOrdinal(name string, v TimeSeriesValue) (int, bool) {
+	p, ok := PeriodicityOf(name)
+	if !ok {
+		return 0, false
+	}
+	year, err := strconv.Atoi(strings.TrimSpace(v.Year))
+	if err != nil {
+		return 0, false
+	}
+
+	period := Period{Periodicity: p, Year: year}
+	switch p {
+	case Quarterly:
+		period.Index = parseQuarter(strings.TrimSpace(v.Quarter))
+	case Monthly:
+		period.Index = int(parseMonth(strings.TrimSpace(v.Month)))
+	}
+	if p != Annual && period.Index == 0 {
+		return 0, false
+	}
+	return period.Ordinal(), true
+}
+
+// minShiftOverlap is the fewest periods that must line up before values are reported as shifted.
+const minShiftOverlap = 3
+
+// detectShift collapses the value changes, and the periods added and removed at the ends, into a single Shift if the
+// actual values are the expected values moved by a few periods. It does the same for the label changes if every label
+// is off by the same number of periods.
+func detectShift(pd *PeriodsDiff, expected, actual []TimeSeriesValue) {
+	if len(pd.Values) >= 2 {
+		detectValueShift(pd, expected, actual)
+	}
+	detectLabelShift(pd, expected, actual)
+}
+
+func detectValueShift(pd *PeriodsDiff, expected, actual []TimeSeriesValue) {
+	expectedByOrdinal, ok := byOrdinal(pd.Name, expected)
+	if !ok {
+		return
+	}
+	actualByOrdinal, ok := byOrdinal(pd.Name, actual)
+	if !ok {
+		return
+	}
+
+	maxShift := 2 * periodsPerYear(pd.Name)
+	if maxShift < 3 {
+		maxShift = 3
+	}
+	for distance := 1; distance <= maxShift; distance++ {
+		for _, k := range []int{distance, -distance} {
+			if !shiftedBy(k, expectedByOrdinal, actualByOrdinal, len(expected)) {
+				continue
+			}
+
+			pd.Shifts = append(pd.Shifts, Shift{Periods: k, Unit: periodUnits[pd.Name]})
+			pd.Values = nil
+			pd.Removed = unexplained(pd.Name, pd.Removed, actualByOrdinal, k)
+			pd.Added = unexplained(pd.Name, pd.Added, expectedByOrdinal, -k)
+			return
+		}
+	}
+}
+
+func byOrdinal(name string, values []TimeSeriesValue) (map[int]string, bool) {
+	m := make(map[int]string)
+	for _, v := range values {
+		o, ok := dateOrdinal(name, v.Date)
+		if !ok {
+			return nil, false
+		}
+		m[o] = v.Value
+	}
+	return m, true
+}
+
+// shiftedBy returns true if the value of every expected period o equals the actual value of period o+k, wherever
+// both exist, and enough of them do.
+func shiftedBy(k int, expected, actual map[int]string, count int) bool {
+	overlap := 0
+	for o, e := range expected {
+		a, ok := actual[o+k]
+		if !ok {
+			continue
+		}
+		if a != e {
+			return false
+		}
+		overlap++
+	}
+	return overlap >= minShiftOverlap && overlap*2 >= count
+}
+
+// unexplained returns the added or removed periods whose value is not found k periods away in the other series.
+func unexplained(name string, periods []PeriodChange, other map[int]string, k int) []PeriodChange {
+	var left []PeriodChange
+	for _, p := range periods {
+		o, _ := dateOrdinal(name, p.Date)
+		if v, ok := other[o+k]; !ok || v != p.Value {
+			left = append(left, p)
+		}
+	}
+	return left
+}
+
+// detectLabelShift replaces the year, quarter and month field changes with a Shift if the labels of every actual
+// value are off from the expected labels of the same date by the same number of periods.
+func detectLabelShift(pd *PeriodsDiff, expected, actual []TimeSeriesValue) {
+	labelChanges := 0
+	for _, f := range pd.Fields {
+		if isLabelField(f.Field) {
+			labelChanges++
+		}
+	}
+	if labelChanges == 0 {
+		return
+	}
+
+	expectedByDate := make(map[string]TimeSeriesValue)
+	for _, e := range expected {
+		expectedByDate[e.Date] = e
+	}
+
+	k, matched := 0, 0
+	for _, a := range actual {
+		e, ok := expectedByDate[a.Date]
+		if !ok {
+			continue
+		}
+		eo, ok := labelOrdinal(pd.Name, e)
+		if !ok {
+			return
+		}
+		ao, ok := labelOrdinal(pd.Name, a)
+		if !ok {
+			return
+		}
+		if matched > 0 && ao-eo != k {
+			return
+		}
+		k = ao - eo
+		matched++
+	}
+	if k == 0 || matched < minShiftOverlap {
+		return
+	}
+
+	pd.Shifts = append(pd.Shifts, Shift{Periods: k, Unit: periodUnits[pd.Name], Labels: true})
+	var fields []FieldChange
+	for _, f := range pd.Fields {
+		if !isLabelField(f.Field) {
+			fields = append(fields, f)
+		}
+	}
+	pd.Fields = fields
+}
+
+func isLabelField(field string) bool {
+	return strings.HasSuffix(field, " year") || strings.HasSuffix(field, " quarter") || strings.HasSuffix(field, " month")
+}
@@ -0,0 +1,32 @@
+// Package timeseries is the JSON model of the time series returned by project-brian's ConvertCSDB service.
+package timeseries
+
+type TimeSeriesValue struct {
+	Date          string `json:"date"`
+	Value         string `json:"value"`
+	Year          string `json:"year"`
+	Month         string `json:"month"`
+	Quarter       string `json:"quarter"`
+	SourceDataset string `json:"sourceDataset"`
+}
+
+type Description struct {
+	Title      string `json:"title"`
+	CDID       string `json:"cdid"`
+	Unit       string `json:"unit"`
+	PreUnit    string `json:"preUnit"`
+	Source     string `json:"source"`
+	Date       string `json:"date"`
+	Number     string `json:"number"`
+	SampleSize int    `json:"sampleSize"`
+}
+
+type TimeSeries struct {
+	Years          []TimeSeriesValue `json:"years"`
+	Quarters       []TimeSeriesValue `json:"quarters"`
+	Months         []TimeSeriesValue `json:"months"`
+	SourceDatasets []string          `json:"sourceDatasets"`
+	Section        interface{}       `json:"section"`
+	Type           string            `json:"type"`
+	Description    Description       `json:"description"`
+}
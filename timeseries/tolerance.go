@@ -0,0 +1,170 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ToleranceMode is how an actual value is compared with the expected value.
+type ToleranceMode string
+
+const (
+	// Exact values are the same string.
+	Exact ToleranceMode = "exact"
+	// Numeric values are the same number, so 1.50 equals 1.5.
+	Numeric ToleranceMode = "numeric"
+	// Absolute values differ by at most the tolerance.
+	Absolute ToleranceMode = "absolute"
+	// Relative values differ by at most the tolerance as a fraction of the expected value, e.g. 0.001 for 0.1%.
+	Relative ToleranceMode = "relative"
+)
+
+// Tolerance compares values. Values that are not both numbers are always compared exactly.
+type Tolerance struct {
+	Mode      ToleranceMode `json:"mode"`
+	Tolerance float64       `json:"tolerance,omitempty"`
+}
+
+// Equal returns true if actual is accepted as the expected value.
+func (t Tolerance) Equal(expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	if t.Mode == Exact || t.Mode == "" {
+		return false
+	}
+	if t.Mode == Numeric {
+		e, err := ParseDecimal(strings.TrimSpace(expected))
+		if err != nil {
+			return false
+		}
+		a, err := ParseDecimal(strings.TrimSpace(actual))
+		return err == nil && a.Cmp(e) == 0
+	}
+
+	e, err := strconv.ParseFloat(strings.TrimSpace(expected), 64)
+	if err != nil {
+		return false
+	}
+	a, err := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	if err != nil {
+		return false
+	}
+
+	diff := math.Abs(a - e)
+	switch t.Mode {
+	case Absolute:
+		return diff <= t.Tolerance
+	case Relative:
+		return diff <= t.Tolerance*math.Abs(e)
+	}
+	return false
+}
+
+func (t Tolerance) String() string {
+	switch t.Mode {
+	case Absolute:
+		return fmt.Sprintf("absolute tolerance %g", t.Tolerance)
+	case Relative:
+		return fmt.Sprintf("relative tolerance %g%%", t.Tolerance*100)
+	case "":
+		return string(Exact)
+	}
+	return string(t.Mode)
+}
+
+func (t Tolerance) validate() error {
+	switch t.Mode {
+	case "", Exact, Numeric, Absolute, Relative:
+	default:
+		return errors.Errorf("unknown tolerance mode %q", t.Mode)
+	}
+	if t.Tolerance < 0 {
+		return errors.Errorf("tolerance %g is negative", t.Tolerance)
+	}
+	return nil
+}
+
+// ToleranceRule applies a tolerance to the series matching every selector given: the dataset name (e.g. ott), the
+// unit of the series and a CDID pattern such as GMA* (see path.Match).
+type ToleranceRule struct {
+	Dataset string `json:"dataset,omitempty"`
+	Unit    string `json:"unit,omitempty"`
+	CDID    string `json:"cdid,omitempty"`
+	Tolerance
+}
+
+func (r ToleranceRule) matches(dataset, unit, cdid string) bool {
+	if r.Dataset != "" && r.Dataset != dataset {
+		return false
+	}
+	if r.Unit != "" && r.Unit != unit {
+		return false
+	}
+	if r.CDID != "" {
+		if ok, _ := path.Match(r.CDID, cdid); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ToleranceRules choose the tolerance of each series: the last rule matching the series, or the default.
+type ToleranceRules struct {
+	Default Tolerance       `json:"default"`
+	Rules   []ToleranceRule `json:"rules"`
+}
+
+// For returns the tolerance of the series with the CDID and unit in the dataset.
+func (r *ToleranceRules) For(dataset, unit, cdid string) Tolerance {
+	if r == nil {
+		return Tolerance{Mode: Exact}
+	}
+	t := r.Default
+	for _, rule := range r.Rules {
+		if rule.matches(dataset, unit, cdid) {
+			t = rule.Tolerance
+		}
+	}
+	return t
+}
+
+// ReadToleranceRules reads tolerance rules from a JSON file such as:
+//
+//	{
+//	  "default": {"mode": "numeric"},
+//	  "rules": [
+//	    {"dataset": "ukea", "mode": "relative", "tolerance": 0.001},
+//	    {"cdid": "GMA*", "unit": "Thousands", "mode": "absolute", "tolerance": 1}
+//	  ]
+//	}
+func ReadToleranceRules(filename string) (*ToleranceRules, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules ToleranceRules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, errors.Wrapf(err, "error reading tolerance rules %s", filename)
+	}
+	if err := rules.Default.validate(); err != nil {
+		return nil, errors.Wrapf(err, "%s: default", filename)
+	}
+	for i, rule := range rules.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, errors.Wrapf(err, "%s: rules[%d]", filename, i)
+		}
+		if _, err := path.Match(rule.CDID, ""); err != nil {
+			return nil, errors.Wrapf(err, "%s: rules[%d]: cdid %q", filename, i, rule.CDID)
+		}
+	}
+	return &rules, nil
+}
@@ -0,0 +1,79 @@
+package timeseries
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTolerance_Equal(t *testing.T) {
+	for _, c := range []struct {
+		tolerance        Tolerance
+		expected, actual string
+		equal            bool
+	}{
+		{Tolerance{}, "1.5", "1.5", true},
+		{Tolerance{}, "1.5", "1.50", false},
+		{Tolerance{Mode: Exact}, "1.5", "1.50", false},
+		{Tolerance{Mode: Numeric}, "1.5", "1.50", true},
+		{Tolerance{Mode: Numeric}, "1.5", "1.51", false},
+		{Tolerance{Mode: Numeric}, "x", "x ", false},
+		{Tolerance{Mode: Absolute, Tolerance: 0.5}, "10", "10.5", true},
+		{Tolerance{Mode: Absolute, Tolerance: 0.5}, "10", "9.4", false},
+		{Tolerance{Mode: Relative, Tolerance: 0.01}, "200", "202", true},
+		{Tolerance{Mode: Relative, Tolerance: 0.01}, "-200", "-203", false},
+		{Tolerance{Mode: Relative, Tolerance: 0.01}, "", "0", false},
+	} {
+		assert.Equal(t, c.equal, c.tolerance.Equal(c.expected, c.actual), "%s: %q and %q", c.tolerance, c.expected, c.actual)
+	}
+}
+
+func TestToleranceRules_For(t *testing.T) {
+	var none *ToleranceRules
+	assert.Equal(t, Tolerance{Mode: Exact}, none.For("ott", "Thousands", "GMAA"))
+
+	rules := &ToleranceRules{
+		Default: Tolerance{Mode: Numeric},
+		Rules: []ToleranceRule{
+			{Dataset: "ukea", Tolerance: Tolerance{Mode: Relative, Tolerance: 0.001}},
+			{CDID: "GMA*", Unit: "Thousands", Tolerance: Tolerance{Mode: Absolute, Tolerance: 1}},
+			{Dataset: "ukea", CDID: "ABMI", Tolerance: Tolerance{Mode: Exact}},
+		},
+	}
+	assert.Equal(t, Numeric, rules.For("ott", "£ million", "GMAA").Mode)
+	assert.Equal(t, Absolute, rules.For("ott", "Thousands", "GMAA").Mode)
+	assert.Equal(t, Numeric, rules.For("ott", "Thousands", "DLBV").Mode)
+	assert.Equal(t, Relative, rules.For("ukea", "£ million", "YBHA").Mode)
+	assert.Equal(t, Exact, rules.For("ukea", "£ million", "ABMI").Mode, "the last matching rule should win")
+}
+
+func TestReadToleranceRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tolerance")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(content string) string {
+		filename := filepath.Join(dir, "rules.json")
+		require.Nil(t, ioutil.WriteFile(filename, []byte(content), 0644))
+		return filename
+	}
+
+	rules, err := ReadToleranceRules(write(`{"default": {"mode": "numeric"}, "rules": [{"cdid": "GMA*", "mode": "relative", "tolerance": 0.001}]}`))
+	require.Nil(t, err)
+	assert.Equal(t, Tolerance{Mode: Numeric}, rules.Default)
+	assert.Equal(t, ToleranceRule{CDID: "GMA*", Tolerance: Tolerance{Mode: Relative, Tolerance: 0.001}}, rules.Rules[0])
+
+	for _, invalid := range []string{
+		`{"default": {"mode": "fuzzy"}}`,
+		`{"rules": [{"mode": "absolute", "tolerance": -1}]}`,
+		`{"rules": [{"cdid": "[GMA", "mode": "exact"}]}`,
+		`{"rules": `,
+	} {
+		_, err := ReadToleranceRules(write(invalid))
+		assert.NotNil(t, err, invalid)
+	}
+}